@@ -0,0 +1,57 @@
+package waitprocess
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+)
+
+// Dump writes a diagnostic dump to w: the stop reason, the status of every process and
+// the stacks of all goroutines
+func (wp *WaitProcess) Dump(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "waitprocess dump at %s\nstop reason: %s\n\n", time.Now().Format(time.RFC3339), wp.StopReason()); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tREADY\tUPTIME\tRESTARTS\tHEALTH\tLAST ERROR")
+	for _, status := range wp.Snapshot() {
+		health := "-"
+		if status.Health != nil {
+			health = "healthy"
+			if !status.Health.Healthy {
+				health = "unhealthy"
+			}
+		}
+
+		lastErr := "-"
+		if status.LastError != nil {
+			lastErr = status.LastError.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%d\t%s\t%s\n",
+			status.Name, status.State, status.Ready, status.Uptime.Round(time.Second), status.Restarts, health, lastErr)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "\n%d goroutines:\n", runtime.NumGoroutine()); err != nil {
+		return err
+	}
+
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+func (wp *WaitProcess) dumpOnSignal(_ context.Context, sig os.Signal) {
+	wp.log.WithField("signal", sig).Info("Writing diagnostic dump")
+	if err := wp.Dump(wp.dumpWriter); err != nil {
+		wp.log.WithError(err).Error("Failed to write diagnostic dump")
+	}
+}
@@ -0,0 +1,168 @@
+package waitprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Phase is the phase of the lifecycle an error happened in
+type Phase int
+
+const (
+	// PhaseRun is an error returned by a running process
+	PhaseRun Phase = iota
+	// PhaseStop is an error returned by a process after it was asked to stop
+	PhaseStop
+	// PhaseHook is an error of a hook
+	PhaseHook
+	// PhaseReload is an error returned by the reload of a process
+	PhaseReload
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseRun:
+		return "run"
+	case PhaseStop:
+		return "stop"
+	case PhaseHook:
+		return "hook"
+	case PhaseReload:
+		return "reload"
+	default:
+		return "unknown"
+	}
+}
+
+// PhaseError is an error of a process or hook, tagged with its name and phase
+type PhaseError struct {
+	Name  string
+	Phase Phase
+	Err   error
+}
+
+func (e *PhaseError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Name, e.Phase, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// ProcessError is an error of a process, with the timing of the run that failed
+type ProcessError struct {
+	Name  string
+	Phase Phase
+	// StartTime and ExitTime are the times the failed run started and exited
+	StartTime time.Time
+	ExitTime  time.Time
+	Runtime   time.Duration
+	Restarts  int
+	Err       error
+	// Panic is the recovered value and Stack the stack trace if the process panicked
+	Panic any
+	Stack []byte
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Name, e.Phase, e.Err)
+}
+
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError holds all errors of a waitprocess in the order they happened,
+// it works with errors.Is and errors.As like the result of errors.Join
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// ErrStoppedBeforeReady is returned by WaitReady when the waitprocess stopped before all processes were ready
+var ErrStoppedBeforeReady = errors.New("WaitProcess stopped before all processes were ready")
+
+// StopTimeoutError is returned when processes failed to stop within their stop timeout
+type StopTimeoutError struct {
+	// Procs are the names of the abandoned processes
+	Procs []string
+}
+
+func (e *StopTimeoutError) Error() string {
+	return fmt.Sprintf("processes failed to stop in time: %s", strings.Join(e.Procs, ", "))
+}
+
+// ShutdownTimeoutError is returned when the stop phase overran the shutdown timeout
+type ShutdownTimeoutError struct {
+	Timeout time.Duration
+	// Procs are the names of the processes still running when the timeout was hit
+	Procs []string
+	// Hooks are the names of the after-stop hooks that had not finished when the timeout was hit
+	Hooks []string
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	running := make([]string, 0, 2)
+	if len(e.Procs) > 0 {
+		running = append(running, "processes "+strings.Join(e.Procs, ", "))
+	}
+
+	if len(e.Hooks) > 0 {
+		running = append(running, "after-stop hooks "+strings.Join(e.Hooks, ", "))
+	}
+
+	return fmt.Sprintf("shutdown timeout after %s, still running: %s", e.Timeout, strings.Join(running, "; "))
+}
+
+// StartupTimeoutError is returned when processes were not ready within the startup timeout
+type StartupTimeoutError struct {
+	Timeout time.Duration
+	// Procs are the names of the processes that were not ready
+	Procs []string
+}
+
+func (e *StartupTimeoutError) Error() string {
+	return fmt.Sprintf("startup timeout after %s, processes not ready: %s", e.Timeout, strings.Join(e.Procs, ", "))
+}
+
+// HealthCheckError is returned when a process failed its health checks and the action is HealthStopGroup
+type HealthCheckError struct {
+	Proc string
+	Err  error
+}
+
+func (e *HealthCheckError) Error() string {
+	return fmt.Sprintf("process %s failed health check: %v", e.Proc, e.Err)
+}
+
+func (e *HealthCheckError) Unwrap() error {
+	return e.Err
+}
+
+// HookTimeoutError is returned when a hook didn't return within its hook timeout
+type HookTimeoutError struct {
+	Hook    string
+	Timeout time.Duration
+}
+
+func (e *HookTimeoutError) Error() string {
+	return fmt.Sprintf("hook %s didn't finish within %s", e.Hook, e.Timeout)
+}
+
+func (e *HookTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
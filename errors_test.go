@@ -0,0 +1,131 @@
+package waitprocess
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMultiError(t *testing.T) {
+	t.Run("error-message", func(t *testing.T) {
+		err := &MultiError{Errors: []error{
+			&PhaseError{Name: "http", Phase: PhaseRun, Err: errors.New("listen failed")},
+			&PhaseError{Name: "worker", Phase: PhaseStop, Err: errors.New("flush failed")},
+		}}
+		assert.EqualError(t, err, "http (run): listen failed\nworker (stop): flush failed")
+	})
+
+	t.Run("is-and-as", func(t *testing.T) {
+		other := errors.New("other")
+		err := &MultiError{Errors: []error{
+			&PhaseError{Name: "http", Phase: PhaseRun, Err: other},
+			&StopTimeoutError{Procs: []string{"worker"}},
+		}}
+
+		assert.ErrorIs(t, err, other)
+
+		var stopErr *StopTimeoutError
+		assert.ErrorAs(t, err, &stopErr)
+
+		var phaseErr *PhaseError
+		assert.ErrorAs(t, err, &phaseErr)
+		assert.Equal(t, "http", phaseErr.Name)
+	})
+
+	t.Run("collect-all", func(t *testing.T) {
+		errRun := errors.New("run")
+		errStop := errors.New("stop")
+
+		wp := NewWaitProcess()
+		wp.RegisterProcess("run", RunWithCtx(func(ctx context.Context) error {
+			return errRun
+		}))
+		wp.RegisterProcess("stop", RunWithCtx(func(ctx context.Context) error {
+			<-ctx.Done()
+			return errStop
+		}))
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, errRun)
+		assert.ErrorIs(t, err, errStop)
+
+		var multiErr *MultiError
+		assert.ErrorAs(t, err, &multiErr)
+		assert.Len(t, multiErr.Errors, 2)
+		assert.EqualError(t, multiErr.Errors[0], "run (run): run")
+		assert.EqualError(t, multiErr.Errors[1], "stop (stop): stop")
+		assert.Equal(t, err, wp.Error())
+	})
+
+	t.Run("no-error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+	})
+}
+
+func TestProcessError(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", RunWithCtx(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return assert.AnError
+		}))
+
+		err := wp.Run()
+
+		var procErr *ProcessError
+		assert.ErrorAs(t, err, &procErr)
+		assert.Equal(t, "error", procErr.Name)
+		assert.Equal(t, PhaseRun, procErr.Phase)
+		assert.ErrorIs(t, procErr, assert.AnError)
+		assert.False(t, procErr.StartTime.IsZero())
+		assert.GreaterOrEqual(t, procErr.Runtime, 10*time.Millisecond)
+		assert.Equal(t, procErr.ExitTime.Sub(procErr.StartTime), procErr.Runtime)
+		assert.Nil(t, procErr.Panic)
+		assert.Nil(t, procErr.Stack)
+	})
+
+	t.Run("restarts", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", withErrprocess(assert.AnError),
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(Backoff{Initial: time.Millisecond}),
+		)
+
+		wp.Start()
+		time.Sleep(50 * time.Millisecond)
+		wp.RemoveProcess("test")
+		wp.Shutdown()
+
+		var procErr *ProcessError
+		assert.ErrorAs(t, wp.Error(), &procErr)
+		assert.Greater(t, procErr.Restarts, 0)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			panic("boom")
+		}))
+
+		assert.Panics(t, func() {
+			wp.Run()
+		})
+
+		var procErr *ProcessError
+		assert.ErrorAs(t, wp.Error(), &procErr)
+		assert.Equal(t, "panic", procErr.Name)
+		assert.Equal(t, "boom", procErr.Panic)
+		assert.Contains(t, string(procErr.Stack), "TestProcessError")
+		assert.EqualError(t, procErr, "panic (run): panic: boom")
+	})
+}
@@ -0,0 +1,83 @@
+package waitprocess
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// osExit is replaced in tests
+var osExit = os.Exit
+
+// Escalation forces the exit of the program when the graceful shutdown hangs: on a stop
+// signal received while already stopping, or when the grace period expires
+type Escalation struct {
+	// GracePeriod forces the exit when the shutdown is not done this long after it began,
+	// zero means only a second stop signal forces it
+	GracePeriod time.Duration
+	// ExitCode is the exit code of the forced exit, 1 if zero
+	ExitCode int
+}
+
+func (e Escalation) exitCode() int {
+	if e.ExitCode == 0 {
+		return 1
+	}
+	return e.ExitCode
+}
+
+// watchEscalation handles the signals received while the waitprocess shuts down, it forces
+// the exit according to the escalation policy. it returns once shutdownDone is closed
+func (wp *WaitProcess) watchEscalation(order []*procstat, shutdownDone <-chan struct{}) {
+	var grace <-chan time.Time
+	if wp.escalation != nil && wp.escalation.GracePeriod > 0 {
+		timer := time.NewTimer(wp.escalation.GracePeriod)
+		defer timer.Stop()
+		grace = timer.C
+	}
+
+	for {
+		select {
+		case <-shutdownDone:
+			return
+		case sig := <-wp.signalChan:
+			wp.events.emit(Event{Type: EventSignalReceived, Signal: sig})
+
+			action := wp.signals[sig]
+			if !action.stop {
+				go action.handle(wp.ctx, sig)
+				continue
+			}
+
+			if wp.escalation == nil {
+				wp.log.WithField("signal", sig).Warn("Received signal, WaitProcess is already stopping")
+				continue
+			}
+
+			wp.forceExit(order, fmt.Sprintf("received signal %v while stopping", sig))
+			return
+		case <-grace:
+			wp.forceExit(order, fmt.Sprintf("shutdown not done after %s", wp.escalation.GracePeriod))
+			return
+		}
+	}
+}
+
+// forceExit logs the processes still running and the goroutine stacks, labelled with the
+// name of the process they belong to, then exits the program
+func (wp *WaitProcess) forceExit(order []*procstat, reason string) {
+	running := make([]string, 0)
+	for _, proc := range order {
+		if !proc.exited() {
+			running = append(running, proc.name)
+		}
+	}
+
+	wp.log.WithField("reason", reason).WithField("procs", running).Error("Forcing exit, processes still running")
+	fmt.Fprintf(wp.dumpWriter, "waitprocess forced exit: %s\nstill running: %s\n\n", reason, strings.Join(running, ", "))
+	pprof.Lookup("goroutine").WriteTo(wp.dumpWriter, 1)
+
+	osExit(wp.escalation.exitCode())
+}
@@ -0,0 +1,112 @@
+package waitprocess
+
+import (
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithEscalation(t *testing.T) {
+	mockExit := func(t *testing.T) <-chan int {
+		codes := make(chan int, 1)
+		exit := osExit
+		osExit = func(code int) {
+			codes <- code
+		}
+		t.Cleanup(func() {
+			osExit = exit
+		})
+		return codes
+	}
+
+	newHanging := func() (Process, func()) {
+		hang := make(chan struct{})
+		return RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {}), func() { close(hang) }
+	}
+
+	t.Run("second-signal", func(t *testing.T) {
+		codes := mockExit(t)
+		buf := &syncbuffer{}
+		wp := NewWaitProcess(WithEscalation(Escalation{ExitCode: 3}), WithDumpWriter(buf))
+		proc, release := newHanging()
+		defer release()
+		wp.RegisterProcess("hanging", proc)
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterSignal(syscall.SIGUSR2)
+		wp.Start()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		assert.Eventually(t, func() bool {
+			return wp.procs.get("test").exited()
+		}, time.Second, time.Millisecond)
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		select {
+		case code := <-codes:
+			assert.Equal(t, 3, code)
+		case <-time.After(time.Second):
+			t.Fatal("exit not forced")
+		}
+
+		dump := buf.String()
+		assert.Contains(t, dump, "still running: hanging\n")
+		assert.Contains(t, dump, `"process":"hanging"`)
+	})
+
+	t.Run("grace-period", func(t *testing.T) {
+		codes := mockExit(t)
+		wp := NewWaitProcess(WithEscalation(Escalation{GracePeriod: 20 * time.Millisecond}), WithDumpWriter(&syncbuffer{}))
+		proc, release := newHanging()
+		defer release()
+		wp.RegisterProcess("hanging", proc)
+		wp.Start()
+		wp.Stop()
+
+		select {
+		case code := <-codes:
+			assert.Equal(t, 1, code)
+		case <-time.After(time.Second):
+			t.Fatal("exit not forced")
+		}
+	})
+
+	t.Run("graceful", func(t *testing.T) {
+		codes := mockExit(t)
+		wp := NewWaitProcess(WithEscalation(Escalation{GracePeriod: 20 * time.Millisecond}))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		assert.Nil(t, wp.Shutdown())
+
+		select {
+		case <-codes:
+			t.Fatal("exit must not be forced")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		codes := mockExit(t)
+		wp := NewWaitProcess()
+		proc, release := newHanging()
+		wp.RegisterProcess("hanging", proc)
+		wp.RegisterSignal(syscall.SIGUSR2)
+		wp.Start()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		time.Sleep(10 * time.Millisecond)
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+
+		select {
+		case <-codes:
+			t.Fatal("exit must not be forced")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		release()
+		assert.Nil(t, wp.Wait(time.Second))
+	})
+}
@@ -0,0 +1,239 @@
+package waitprocess
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// EventType is the type of a lifecycle event
+type EventType int
+
+const (
+	// EventGroupStarting is emitted when the waitprocess starts, before the pre-start hooks
+	EventGroupStarting EventType = iota
+	// EventGroupStarted is emitted when all processes are launched
+	EventGroupStarted
+	// EventGroupStopping is emitted once when the waitprocess begins to stop
+	EventGroupStopping
+	// EventGroupStopped is emitted when the processes are stopped and the after-stop hooks ran
+	EventGroupStopped
+	// EventProcessStarted is emitted every time a process is run, including restarts
+	EventProcessStarted
+	// EventProcessExited is emitted when a run of a process returns without error
+	EventProcessExited
+	// EventProcessFailed is emitted when a run of a process returns an error
+	EventProcessFailed
+	// EventProcessPanicked is emitted when a run of a process panics
+	EventProcessPanicked
+	// EventProcessRestarted is emitted before a process is run again
+	EventProcessRestarted
+	// EventHookStarted is emitted before a hook runs
+	EventHookStarted
+	// EventHookFinished is emitted after a hook returned
+	EventHookFinished
+	// EventSignalReceived is emitted when a registered signal is received
+	EventSignalReceived
+	// EventProcessReloaded is emitted when a process was reloaded, Err is set if it failed
+	EventProcessReloaded
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventGroupStarting:
+		return "group-starting"
+	case EventGroupStarted:
+		return "group-started"
+	case EventGroupStopping:
+		return "group-stopping"
+	case EventGroupStopped:
+		return "group-stopped"
+	case EventProcessStarted:
+		return "process-started"
+	case EventProcessExited:
+		return "process-exited"
+	case EventProcessFailed:
+		return "process-failed"
+	case EventProcessPanicked:
+		return "process-panicked"
+	case EventProcessRestarted:
+		return "process-restarted"
+	case EventHookStarted:
+		return "hook-started"
+	case EventHookFinished:
+		return "hook-finished"
+	case EventSignalReceived:
+		return "signal-received"
+	case EventProcessReloaded:
+		return "process-reloaded"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a lifecycle event of the waitprocess
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Proc is the name of the process for the process events
+	Proc string
+	// Hook is the name of the hook for the hook events
+	Hook string
+	// Signal is the received signal for EventSignalReceived
+	Signal os.Signal
+	// Reason is why the waitprocess stops for EventGroupStopping
+	Reason StopReason
+	// Err is the error of the failed, panicked or reloaded process, or the restart cause
+	Err error
+}
+
+// Subscribe calls f with every event emitted from now on, in order. f is called from a
+// goroutine of its own, a slow subscriber doesn't block the waitprocess nor the other
+// subscribers. the returned function cancels the subscription
+func (wp *WaitProcess) Subscribe(f func(Event)) (unsubscribe func()) {
+	sub := wp.events.subscribe(f)
+	return func() {
+		wp.events.unsubscribe(sub)
+	}
+}
+
+// Events returns a channel receiving every event emitted from now on, in order. the channel
+// is closed after EventGroupStopped, it must be drained until then
+func (wp *WaitProcess) Events() <-chan Event {
+	ch := make(chan Event)
+	sub := wp.events.subscribe(func(event Event) {
+		ch <- event
+	})
+
+	go func() {
+		<-sub.done
+		close(ch)
+	}()
+
+	return ch
+}
+
+// eventBus delivers the emitted events to the subscribers, every subscriber has a queue of
+// its own so emitting never blocks
+type eventBus struct {
+	lock   sync.Mutex
+	subs   []*subscriber
+	closed bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{}
+}
+
+func (b *eventBus) subscribe(f func(Event)) *subscriber {
+	sub := &subscriber{f: f, done: make(chan struct{})}
+	sub.cond = sync.NewCond(&sub.lock)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		close(sub.done)
+		return sub
+	}
+
+	b.subs = append(b.subs, sub)
+	go sub.loop()
+	return sub
+}
+
+func (b *eventBus) unsubscribe(sub *subscriber) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			sub.close(true)
+			return
+		}
+	}
+}
+
+// emit timestamps the event and queues it for all subscribers, events emitted after close
+// are dropped
+func (b *eventBus) emit(event Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	event.Time = time.Now()
+	for _, sub := range b.subs {
+		sub.push(event)
+	}
+}
+
+// close stops accepting events, the subscribers still receive the queued ones
+func (b *eventBus) close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for _, sub := range b.subs {
+		sub.close(false)
+	}
+	b.subs = nil
+}
+
+type subscriber struct {
+	lock   sync.Mutex
+	cond   *sync.Cond
+	queue  []Event
+	closed bool
+	f      func(Event)
+	done   chan struct{}
+}
+
+func (s *subscriber) push(event Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.queue = append(s.queue, event)
+	s.cond.Signal()
+}
+
+// close ends the subscription once the queue is drained, or right away if drop is true
+func (s *subscriber) close(drop bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	if drop {
+		s.queue = nil
+	}
+	s.cond.Signal()
+}
+
+func (s *subscriber) loop() {
+	defer close(s.done)
+
+	for {
+		s.lock.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+
+		if len(s.queue) == 0 {
+			s.lock.Unlock()
+			return
+		}
+
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		s.f(event)
+	}
+}
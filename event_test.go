@@ -0,0 +1,183 @@
+package waitprocess
+
+import (
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	types := func(events []Event) []EventType {
+		result := make([]EventType, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Type)
+		}
+		return result
+	}
+
+	collect := func(ch <-chan Event) []Event {
+		events := make([]Event, 0)
+		for event := range ch {
+			events = append(events, event)
+		}
+		return events
+	}
+
+	t.Run("lifecycle", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHook("pre", func() {})
+		wp.AfterStopHook("after", func() {})
+		ch := wp.Events()
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+
+		events := collect(ch)
+		group := make([]Event, 0)
+		for _, event := range events {
+			if event.Proc == "" {
+				group = append(group, event)
+			}
+		}
+
+		assert.Equal(t, []EventType{
+			EventGroupStarting,
+			EventHookStarted,
+			EventHookFinished,
+			EventGroupStarted,
+			EventGroupStopping,
+			EventHookStarted,
+			EventHookFinished,
+			EventGroupStopped,
+		}, types(group))
+		assert.Equal(t, "pre", group[1].Hook)
+		assert.Equal(t, "after", group[5].Hook)
+		assert.Equal(t, StopReasonStop, group[4].Reason.Kind)
+
+		// the process runs in between
+		started := findIndex(events, EventProcessStarted)
+		exited := findIndex(events, EventProcessExited)
+		assert.Less(t, findIndex(events, EventHookFinished), started)
+		assert.Less(t, started, exited)
+		assert.Less(t, exited, len(events)-3)
+		assert.Equal(t, "test", events[exited].Proc)
+
+		for i := 1; i < len(events); i++ {
+			assert.False(t, events[i].Time.Before(events[i-1].Time))
+		}
+	})
+
+	t.Run("failed-and-restarted", func(t *testing.T) {
+		wp := NewWaitProcess()
+		stat := &teststate{}
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			stat.add()
+			if stat.getstate() < 3 {
+				return assert.AnError
+			}
+			return nil
+		}, func() {}), WithRestartPolicy(RestartOnFailure), WithBackoff(Backoff{Initial: time.Millisecond}))
+		ch := wp.Events()
+
+		err := wp.Run()
+		assert.Nil(t, err)
+
+		events := make([]Event, 0)
+		for _, event := range collect(ch) {
+			if event.Proc == "test" {
+				events = append(events, event)
+			}
+		}
+
+		assert.Equal(t, []EventType{
+			EventProcessStarted,
+			EventProcessFailed,
+			EventProcessRestarted,
+			EventProcessStarted,
+			EventProcessFailed,
+			EventProcessRestarted,
+			EventProcessStarted,
+			EventProcessExited,
+		}, types(events))
+		assert.ErrorIs(t, events[1].Err, assert.AnError)
+		assert.ErrorIs(t, events[2].Err, assert.AnError)
+	})
+
+	t.Run("panicked", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicAsError))
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			panic("boom")
+		}, func() {}))
+		ch := wp.Events()
+
+		err := wp.Run()
+		assert.NotNil(t, err)
+
+		event := findEvent(collect(ch), EventProcessPanicked)
+		assert.Equal(t, "test", event.Proc)
+		assert.EqualError(t, event.Err, "panic: boom")
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterSignal(syscall.SIGUSR2)
+		ch := wp.Events()
+		wp.Start()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		wp.Wait()
+
+		events := collect(ch)
+		assert.Equal(t, syscall.SIGUSR2, findEvent(events, EventSignalReceived).Signal)
+		assert.Equal(t, StopReasonSignal, findEvent(events, EventGroupStopping).Reason.Kind)
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+
+		recorder := &testrecorder{}
+		unsubscribe := wp.Subscribe(func(event Event) {
+			recorder.add(event.Type.String())
+		})
+
+		wp.Start()
+		for len(recorder.get()) < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		unsubscribe()
+		wp.Shutdown()
+
+		assert.ElementsMatch(t, []string{"group-starting", "process-started", "group-started"}, recorder.get())
+	})
+
+	t.Run("after-stop", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		wp.Shutdown()
+
+		_, ok := <-wp.Events()
+		assert.False(t, ok)
+	})
+}
+
+func findIndex(events []Event, typ EventType) int {
+	for i, event := range events {
+		if event.Type == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+func findEvent(events []Event, typ EventType) Event {
+	if i := findIndex(events, typ); i >= 0 {
+		return events[i]
+	}
+	return Event{}
+}
@@ -0,0 +1,340 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/siriusa51/waitprocess/v2/ext/http_srv"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type adminOption struct {
+	wp       *waitprocess.WaitProcess
+	name     string
+	timeout  time.Duration
+	log      waitprocess.Logger
+	token    string
+	unix     bool
+	history  int
+	procOpts []waitprocess.ProcessOption
+}
+
+type AdminOptionFunc func(*adminOption)
+
+func newAdminOption(opts ...AdminOptionFunc) *adminOption {
+	opt := &adminOption{
+		name:    "admin",
+		timeout: time.Second * 15,
+		wp:      waitprocess.Default(),
+		log:     waitprocess.NewLogrusLogger(logrus.WithField("pkg", "waitprocess/admin")),
+		history: 100,
+	}
+
+	for _, o := range opts {
+		o(opt)
+	}
+	return opt
+}
+
+// WithName sets the name the admin server is registered with, "admin" by default
+func WithName(name string) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.name = name
+	}
+}
+
+// WithTimeout sets how long the admin server waits for pending requests when it stops, and
+// for a restarted process to run again
+func WithTimeout(timeout time.Duration) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.timeout = timeout
+	}
+}
+
+// WithWaitProcess sets the waitprocess the admin server controls and is registered to
+func WithWaitProcess(wp *waitprocess.WaitProcess) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.wp = wp
+	}
+}
+
+// WithLogger sets the logger of the admin server
+func WithLogger(log waitprocess.Logger) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.log = log
+	}
+}
+
+// WithToken requires every request to carry the token as "Authorization: Bearer <token>"
+func WithToken(token string) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.token = token
+	}
+}
+
+// WithUnixSocket makes the admin server listen on a unix socket, addr is then the socket path.
+// the socket is only accessible by its owner
+func WithUnixSocket() AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.unix = true
+	}
+}
+
+// WithEventHistory sets how many recent lifecycle events are kept, 100 by default
+func WithEventHistory(size int) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.history = size
+	}
+}
+
+// WithProcessOptions sets the options the admin server is registered with
+func WithProcessOptions(opts ...waitprocess.ProcessOption) AdminOptionFunc {
+	return func(opt *adminOption) {
+		opt.procOpts = append(opt.procOpts, opts...)
+	}
+}
+
+// RegisterAdminSrv registers an admin server controlling the waitprocess, it serves:
+//
+//	GET  /                               HTML status page
+//	GET  /api/processes                  status of all processes
+//	GET  /api/processes/{name}           status of a process
+//	POST /api/processes/{name}/stop      stop a process
+//	POST /api/processes/{name}/restart   restart a process and wait for it to run again
+//	POST /api/processes/{name}/remove    remove a process
+//	POST /api/shutdown                   stop the waitprocess
+//	POST /api/reload                     reload the processes implementing waitprocess.Reloadable
+//	GET  /api/events?limit=n             recent lifecycle events
+func RegisterAdminSrv(addr string, fs ...AdminOptionFunc) *waitprocess.WaitProcess {
+	opt := newAdminOption(fs...)
+
+	if opt.token == "" && !opt.unix {
+		opt.log.WithField("addr", addr).Warn("Admin server is not protected by a token nor a unix socket")
+	}
+
+	srvOpts := []http_srv.HttpServerOptionFunc{
+		http_srv.WithName(opt.name),
+		http_srv.WithTimeout(opt.timeout),
+		http_srv.WithWaitProcess(opt.wp),
+		http_srv.WithLogger(opt.log),
+		http_srv.WithProcessOptions(opt.procOpts...),
+	}
+
+	if opt.unix {
+		srvOpts = append(srvOpts, http_srv.WithNetwork("unix"))
+	}
+
+	return http_srv.RegisterHttpSrv(addr, newServer(opt).handler(), srvOpts...)
+}
+
+type server struct {
+	opt    *adminOption
+	wp     *waitprocess.WaitProcess
+	lock   sync.Mutex
+	events []waitprocess.Event
+}
+
+func newServer(opt *adminOption) *server {
+	s := &server{opt: opt, wp: opt.wp}
+	opt.wp.Subscribe(s.record)
+	return s
+}
+
+// record keeps the event in the history, dropping the oldest one when it is full
+func (s *server) record(event waitprocess.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.opt.history <= 0 {
+		return
+	}
+
+	if len(s.events) >= s.opt.history {
+		s.events = append(s.events[:0], s.events[len(s.events)-s.opt.history+1:]...)
+	}
+	s.events = append(s.events, event)
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.statusPage)
+	mux.HandleFunc("GET /api/processes", s.listProcesses)
+	mux.HandleFunc("GET /api/processes/{name}", s.getProcess)
+	mux.HandleFunc("POST /api/processes/{name}/stop", s.controlProcess(s.wp.StopProcess))
+	mux.HandleFunc("POST /api/processes/{name}/restart", s.controlProcess(s.restartProcess))
+	mux.HandleFunc("POST /api/processes/{name}/remove", s.controlProcess(s.wp.RemoveProcess))
+	mux.HandleFunc("POST /api/shutdown", s.shutdown)
+	mux.HandleFunc("POST /api/reload", s.reload)
+	mux.HandleFunc("GET /api/events", s.listEvents)
+
+	if s.opt.token == "" {
+		return mux
+	}
+
+	expected := []byte("Bearer " + s.opt.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) listProcesses(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.wp.Snapshot()
+	procs := make([]processStatus, 0, len(snapshot))
+	for _, status := range snapshot {
+		procs = append(procs, newProcessStatus(status))
+	}
+
+	writeJSON(w, http.StatusOK, procs)
+}
+
+func (s *server) getProcess(w http.ResponseWriter, r *http.Request) {
+	status, ok := s.findProcess(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("process not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProcessStatus(status))
+}
+
+func (s *server) findProcess(name string) (waitprocess.ProcessStatus, bool) {
+	for _, status := range s.wp.Snapshot() {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return waitprocess.ProcessStatus{}, false
+}
+
+// controlProcess returns a handler applying f to the named process, it answers with the
+// status of the process, or with a removeResult if the process was removed
+func (s *server) controlProcess(f func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if _, ok := s.findProcess(name); !ok {
+			writeError(w, http.StatusNotFound, errors.New("process not found"))
+			return
+		}
+
+		// the admin server would wait for this very request to finish
+		if name == s.opt.name {
+			writeError(w, http.StatusConflict, errors.New("the admin server can't control itself"))
+			return
+		}
+
+		if err := f(name); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		s.opt.log.WithField("proc", name).WithField("path", r.URL.Path).Info("Admin request done")
+		status, ok := s.findProcess(name)
+		if !ok {
+			writeJSON(w, http.StatusOK, removeResult{Name: name, Removed: true})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newProcessStatus(status))
+	}
+}
+
+// restartProcess restarts the process and waits for it to run again, bounded by the timeout.
+// it fails if the process exits instead, e.g. because it can't run again after Stop
+func (s *server) restartProcess(name string) error {
+	before, _ := s.findProcess(name)
+	if err := s.wp.RestartProcess(name); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(s.opt.timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+
+	for {
+		status, ok := s.findProcess(name)
+		switch {
+		case !ok:
+			return fmt.Errorf("process %s was removed during the restart", name)
+		case status.Restarts > before.Restarts && status.State == waitprocess.ProcessRunning:
+			return nil
+		case status.State == waitprocess.ProcessStopped || status.State == waitprocess.ProcessFailed:
+			return fmt.Errorf("process %s %s after the restart", name, status.State)
+		}
+
+		select {
+		case <-timer.C:
+			return fmt.Errorf("process %s is not running %v after the restart", name, s.opt.timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *server) shutdown(w http.ResponseWriter, r *http.Request) {
+	s.opt.log.Info("Shutdown requested by admin server")
+	// stopping waits for this request, it must not block it
+	go s.wp.Stop()
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "stopping"})
+}
+
+func (s *server) reload(w http.ResponseWriter, r *http.Request) {
+	err := s.wp.Reload(r.Context())
+	if err == nil {
+		writeJSON(w, http.StatusOK, reloadResult{OK: true, Errors: []processError{}})
+		return
+	}
+
+	result := reloadResult{Errors: []processError{}}
+	var multi *waitprocess.MultiError
+	if errors.As(err, &multi) {
+		for _, err := range multi.Errors {
+			procErr := processError{Error: err.Error()}
+			var phaseErr *waitprocess.PhaseError
+			if errors.As(err, &phaseErr) {
+				procErr.Process = phaseErr.Name
+				procErr.Error = phaseErr.Err.Error()
+			}
+			result.Errors = append(result.Errors, procErr)
+		}
+	}
+
+	writeJSON(w, http.StatusInternalServerError, result)
+}
+
+func (s *server) listEvents(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	events := make([]event, 0, len(s.events))
+	for _, e := range s.events {
+		events = append(events, newEvent(e))
+	}
+	s.lock.Unlock()
+
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(events) {
+		events = events[len(events)-limit:]
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": strings.TrimSpace(err.Error())})
+}
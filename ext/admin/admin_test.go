@@ -0,0 +1,252 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/siriusa51/waitprocess/v2/ext/http_srv"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type reloadprocess struct {
+	waitprocess.Process
+	err error
+}
+
+func (rp *reloadprocess) Reload(_ context.Context) error {
+	return rp.err
+}
+
+func waitCtx() waitprocess.Process {
+	return waitprocess.RunWithCtx(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+}
+
+// newTestServer starts a waitprocess with a few processes and serves its admin handler, a
+// placeholder process takes the name of the admin server
+func newTestServer(t *testing.T, fs ...AdminOptionFunc) (*waitprocess.WaitProcess, *httptest.Server) {
+	nop := waitprocess.NewNopLogger()
+	wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(nop))
+	wp.RegisterProcess("admin", waitCtx())
+	wp.RegisterProcess("worker", waitCtx())
+	wp.RegisterProcess("other", waitCtx())
+	http_srv.RegisterHttpSrv(filepath.Join(t.TempDir(), "web.sock"), http.NotFoundHandler(),
+		http_srv.WithName("web"),
+		http_srv.WithNetwork("unix"),
+		http_srv.WithWaitProcess(wp),
+		http_srv.WithLogger(nop),
+	)
+
+	opt := newAdminOption(append([]AdminOptionFunc{WithWaitProcess(wp), WithLogger(nop)}, fs...)...)
+	srv := httptest.NewServer(newServer(opt).handler())
+	t.Cleanup(srv.Close)
+	return wp, srv
+}
+
+func start(t *testing.T, wp *waitprocess.WaitProcess) {
+	assert.Nil(t, wp.Start())
+	assert.Nil(t, wp.WaitReady(context.Background()))
+	t.Cleanup(func() {
+		if !wp.Stopped() {
+			wp.Shutdown(time.Second * 5)
+		}
+	})
+}
+
+func do(t *testing.T, client *http.Client, method, url string, v any, header ...string) int {
+	req, err := http.NewRequest(method, url, nil)
+	assert.Nil(t, err)
+	if len(header) > 0 {
+		req.Header.Set("Authorization", header[0])
+	}
+
+	resp, err := client.Do(req)
+	if !assert.Nil(t, err) {
+		return 0
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp.StatusCode
+}
+
+func TestToken(t *testing.T) {
+	wp, srv := newTestServer(t, WithToken("secret"))
+	start(t, wp)
+
+	assert.Equal(t, http.StatusUnauthorized, do(t, srv.Client(), "GET", srv.URL+"/api/processes", nil))
+	assert.Equal(t, http.StatusUnauthorized, do(t, srv.Client(), "GET", srv.URL+"/api/processes", nil, "Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, do(t, srv.Client(), "GET", srv.URL+"/api/processes", nil, "secret"))
+
+	var procs []processStatus
+	assert.Equal(t, http.StatusOK, do(t, srv.Client(), "GET", srv.URL+"/api/processes", &procs, "Bearer secret"))
+	assert.Len(t, procs, 4)
+}
+
+func TestProcesses(t *testing.T) {
+	wp, srv := newTestServer(t)
+	start(t, wp)
+	client := srv.Client()
+
+	t.Run("list", func(t *testing.T) {
+		var procs []processStatus
+		assert.Equal(t, http.StatusOK, do(t, client, "GET", srv.URL+"/api/processes", &procs))
+		assert.Equal(t, []string{"admin", "worker", "other", "web"}, []string{procs[0].Name, procs[1].Name, procs[2].Name, procs[3].Name})
+		assert.Equal(t, "running", procs[1].State)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(t, client, "GET", srv.URL+"/api/processes/unknown", nil))
+		for _, action := range []string{"stop", "restart", "remove"} {
+			assert.Equal(t, http.StatusNotFound, do(t, client, "POST", srv.URL+"/api/processes/unknown/"+action, nil))
+		}
+	})
+
+	t.Run("self", func(t *testing.T) {
+		var res map[string]string
+		assert.Equal(t, http.StatusConflict, do(t, client, "POST", srv.URL+"/api/processes/admin/stop", &res))
+		assert.Equal(t, "the admin server can't control itself", res["error"])
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		for _, name := range []string{"worker", "web"} {
+			var status processStatus
+			assert.Equal(t, http.StatusOK, do(t, client, "POST", srv.URL+"/api/processes/"+name+"/restart", &status))
+			assert.Equal(t, "running", status.State)
+			assert.Equal(t, 1, status.Restarts)
+		}
+		assert.False(t, wp.Stopped())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var status processStatus
+		assert.Equal(t, http.StatusOK, do(t, client, "POST", srv.URL+"/api/processes/worker/stop", &status))
+		assert.Equal(t, "stopped", status.State)
+
+		var res map[string]string
+		assert.Equal(t, http.StatusConflict, do(t, client, "POST", srv.URL+"/api/processes/worker/stop", &res))
+		assert.Equal(t, "process worker is already stopped", res["error"])
+		assert.False(t, wp.Stopped())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		var res removeResult
+		assert.Equal(t, http.StatusOK, do(t, client, "POST", srv.URL+"/api/processes/other/remove", &res))
+		assert.Equal(t, removeResult{Name: "other", Removed: true}, res)
+		assert.Equal(t, http.StatusNotFound, do(t, client, "GET", srv.URL+"/api/processes/other", nil))
+		assert.False(t, wp.Stopped())
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		var res map[string]string
+		assert.Equal(t, http.StatusAccepted, do(t, client, "POST", srv.URL+"/api/shutdown", &res))
+		assert.Equal(t, "stopping", res["status"])
+		assert.Nil(t, wp.Wait(time.Second*5))
+		assert.Equal(t, waitprocess.StopReasonStop, wp.StopReason().Kind)
+	})
+}
+
+func TestRestartNotRunnable(t *testing.T) {
+	wp, srv := newTestServer(t)
+
+	// the process can't run again after Stop, it exits right after the restart
+	stopped := make(chan struct{})
+	once := sync.Once{}
+	wp.RegisterProcess("oneshot", waitprocess.RunWithStopFunc(func() error {
+		<-stopped
+		return nil
+	}, func() {
+		once.Do(func() { close(stopped) })
+	}), waitprocess.WithExitPolicy(waitprocess.IgnoreExit))
+	start(t, wp)
+
+	var res map[string]string
+	assert.Equal(t, http.StatusConflict, do(t, srv.Client(), "POST", srv.URL+"/api/processes/oneshot/restart", &res))
+	assert.Equal(t, "process oneshot stopped after the restart", res["error"])
+	assert.False(t, wp.Stopped())
+}
+
+func TestReload(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		wp, srv := newTestServer(t)
+		wp.RegisterProcess("reload", &reloadprocess{Process: waitCtx()})
+		start(t, wp)
+
+		var res reloadResult
+		assert.Equal(t, http.StatusOK, do(t, srv.Client(), "POST", srv.URL+"/api/reload", &res))
+		assert.Equal(t, reloadResult{OK: true, Errors: []processError{}}, res)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		wp, srv := newTestServer(t)
+		wp.RegisterProcess("reload", &reloadprocess{Process: waitCtx(), err: assert.AnError})
+		start(t, wp)
+
+		var res reloadResult
+		assert.Equal(t, http.StatusInternalServerError, do(t, srv.Client(), "POST", srv.URL+"/api/reload", &res))
+		assert.Equal(t, reloadResult{Errors: []processError{{Process: "reload", Error: assert.AnError.Error()}}}, res)
+		assert.False(t, wp.Stopped())
+	})
+}
+
+func TestEvents(t *testing.T) {
+	wp, srv := newTestServer(t, WithEventHistory(3))
+	start(t, wp)
+
+	assert.Nil(t, wp.RestartProcess("worker"))
+	assert.Eventually(t, func() bool {
+		var events []event
+		do(t, srv.Client(), "GET", srv.URL+"/api/events", &events)
+		return len(events) == 3 && events[2].Type == "process-started" && events[2].Process == "worker"
+	}, time.Second*5, time.Millisecond*10)
+
+	var events []event
+	assert.Equal(t, http.StatusOK, do(t, srv.Client(), "GET", srv.URL+"/api/events?limit=1", &events))
+	assert.Len(t, events, 1)
+	assert.Equal(t, "process-started", events[0].Type)
+
+	assert.Equal(t, http.StatusOK, do(t, srv.Client(), "GET", srv.URL+"/api/events?limit=10", &events))
+	assert.Len(t, events, 3)
+	assert.Equal(t, "process-restarted", events[1].Type)
+}
+
+func TestRegisterAdminSrv(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	nop := waitprocess.NewNopLogger()
+	wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(nop))
+	wp.RegisterProcess("worker", waitCtx())
+	RegisterAdminSrv(sock, WithWaitProcess(wp), WithUnixSocket(), WithLogger(nop))
+	start(t, wp)
+
+	info, err := os.Stat(sock)
+	assert.Nil(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		},
+	}
+
+	var status processStatus
+	assert.Equal(t, http.StatusOK, do(t, client, "GET", "http://admin/api/processes/admin", &status))
+	assert.Equal(t, "running", status.State)
+
+	assert.Equal(t, http.StatusAccepted, do(t, client, "POST", "http://admin/api/shutdown", nil))
+	assert.Nil(t, wp.Wait(time.Second*5))
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>waitprocess</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.running { color: #080; }
+.failed { color: #c00; }
+</style>
+</head>
+<body>
+<h1>waitprocess</h1>
+<p>Stop reason: {{.StopReason}}</p>
+<table>
+<tr><th>Name</th><th>State</th><th>Ready</th><th>Uptime</th><th>Restarts</th><th>Health</th><th>Last error</th></tr>
+{{range .Procs}}<tr>
+<td>{{.Name}}</td>
+<td class="{{.State}}">{{.State}}</td>
+<td>{{.Ready}}</td>
+<td>{{.Uptime}}</td>
+<td>{{.Restarts}}</td>
+<td>{{if .Health}}{{if .Health.Healthy}}healthy{{else}}unhealthy{{end}}{{else}}-{{end}}</td>
+<td>{{.LastError}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type pageProcess struct {
+	processStatus
+	Uptime time.Duration
+}
+
+func (s *server) statusPage(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.wp.Snapshot()
+	procs := make([]pageProcess, 0, len(snapshot))
+	for _, status := range snapshot {
+		procs = append(procs, pageProcess{processStatus: newProcessStatus(status), Uptime: status.Uptime.Round(time.Second)})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := statusTemplate.Execute(w, map[string]any{
+		"StopReason": s.wp.StopReason(),
+		"Procs":      procs,
+	})
+	if err != nil {
+		s.opt.log.WithError(err).Error("Failed to render status page")
+	}
+}
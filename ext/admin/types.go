@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"fmt"
+	"github.com/siriusa51/waitprocess/v2"
+	"time"
+)
+
+type healthStatus struct {
+	Healthy             bool       `json:"healthy"`
+	LastCheck           *time.Time `json:"last_check,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+type processStatus struct {
+	Name          string        `json:"name"`
+	State         string        `json:"state"`
+	Ready         bool          `json:"ready"`
+	StartTime     *time.Time    `json:"start_time,omitempty"`
+	UptimeSeconds float64       `json:"uptime_seconds"`
+	Restarts      int           `json:"restarts"`
+	LastError     string        `json:"last_error,omitempty"`
+	Health        *healthStatus `json:"health,omitempty"`
+}
+
+func newProcessStatus(status waitprocess.ProcessStatus) processStatus {
+	result := processStatus{
+		Name:          status.Name,
+		State:         status.State.String(),
+		Ready:         status.Ready,
+		StartTime:     optionalTime(status.StartTime),
+		UptimeSeconds: status.Uptime.Seconds(),
+		Restarts:      status.Restarts,
+		LastError:     errorString(status.LastError),
+	}
+
+	if status.Health != nil {
+		result.Health = &healthStatus{
+			Healthy:             status.Health.Healthy,
+			LastCheck:           optionalTime(status.Health.LastCheck),
+			LastError:           errorString(status.Health.LastError),
+			ConsecutiveFailures: status.Health.ConsecutiveFailures,
+		}
+	}
+
+	return result
+}
+
+type event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Process string    `json:"process,omitempty"`
+	Hook    string    `json:"hook,omitempty"`
+	Signal  string    `json:"signal,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func newEvent(e waitprocess.Event) event {
+	result := event{
+		Type:    e.Type.String(),
+		Time:    e.Time,
+		Process: e.Proc,
+		Hook:    e.Hook,
+		Error:   errorString(e.Err),
+	}
+
+	if e.Signal != nil {
+		result.Signal = fmt.Sprint(e.Signal)
+	}
+
+	if e.Reason.Kind != waitprocess.StopReasonNone {
+		result.Reason = e.Reason.String()
+	}
+
+	return result
+}
+
+type processError struct {
+	Process string `json:"process,omitempty"`
+	Error   string `json:"error"`
+}
+
+type removeResult struct {
+	Name    string `json:"name"`
+	Removed bool   `json:"removed"`
+}
+
+type reloadResult struct {
+	OK     bool           `json:"ok"`
+	Errors []processError `json:"errors"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,34 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExitError is returned by the run of a command that exited with a non-zero code or was killed
+type ExitError struct {
+	Command string
+	// Code is the exit code of the command, -1 if it was killed by a signal
+	Code int
+	// Signal is the signal that killed the command, nil if it exited
+	Signal os.Signal
+	// Killed is true if the command was killed because it didn't stop within the stop timeout
+	Killed bool
+	Err    error
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("command %s exited with code %d", e.Command, e.Code)
+	if e.Signal != nil {
+		msg = fmt.Sprintf("command %s killed by signal %v", e.Command, e.Signal)
+	}
+
+	if e.Killed {
+		msg += " after stop timeout"
+	}
+	return msg
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
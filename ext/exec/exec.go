@@ -0,0 +1,320 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"github.com/siriusa51/waitprocess/v2"
+	"io"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// waitDelay bounds how long Run waits for the output of the command once it exited, children
+// left holding the output open would block it otherwise
+const waitDelay = time.Second
+
+type commandOption struct {
+	stopSignal  os.Signal
+	stopTimeout time.Duration
+	dir         string
+	env         []string
+	stdout      io.Writer
+	stderr      io.Writer
+	name        string
+	sinks       []sink
+	bufferLines int
+}
+
+type CommandOptionFunc func(*commandOption)
+
+func newCommandOption(opts ...CommandOptionFunc) *commandOption {
+	opt := &commandOption{
+		stopSignal:  syscall.SIGTERM,
+		stopTimeout: time.Second * 10,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		bufferLines: defaultOutputBuffer,
+	}
+
+	for _, o := range opts {
+		o(opt)
+	}
+	return opt
+}
+
+// WithStopSignal sets the signal sent to the process group of the command on Stop, SIGTERM by default
+func WithStopSignal(sig os.Signal) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.stopSignal = sig
+	}
+}
+
+// WithStopTimeout sets how long the command may take to exit after the stop signal, after
+// that its process group is killed with SIGKILL. 10 seconds by default
+func WithStopTimeout(timeout time.Duration) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.stopTimeout = timeout
+	}
+}
+
+// WithDir sets the working directory of the command
+func WithDir(dir string) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.dir = dir
+	}
+}
+
+// WithEnv sets the environment of the command, the environment of the current process by default
+func WithEnv(env ...string) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.env = env
+	}
+}
+
+// WithStdout sets where the standard output of the command is written, os.Stdout by default.
+// it's not used if the output is captured with WithLogOutput or WithOutput
+func WithStdout(w io.Writer) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.stdout = w
+	}
+}
+
+// WithStderr sets where the standard error of the command is written, os.Stderr by default.
+// it's not used if the output is captured with WithLogOutput or WithOutput
+func WithStderr(w io.Writer) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.stderr = w
+	}
+}
+
+// WithName sets the name of the command in the captured output, the name the process is
+// registered with by default, or the base name of the path if it isn't run by a waitprocess
+func WithName(name string) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.name = name
+	}
+}
+
+// WithLogOutput captures stdout and stderr of the command line by line and writes every
+// line to log with the name of the command and the stream as fields, e.g. to the logger of
+// the waitprocess with WithLogOutput(wp.Logger())
+func WithLogOutput(log waitprocess.Logger) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.sinks = append(opt.sinks, &logSink{log: log})
+	}
+}
+
+// WithOutput captures stdout and stderr of the command line by line and writes every line
+// to out prefixed with the name of the command, out is usually shared by several commands
+func WithOutput(out *Output) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.sinks = append(opt.sinks, out)
+	}
+}
+
+// WithOutputBuffer sets the number of captured lines buffered while the output is slower
+// than the command, further lines are dropped. 1024 by default
+func WithOutputBuffer(lines int) CommandOptionFunc {
+	return func(opt *commandOption) {
+		opt.bufferLines = lines
+	}
+}
+
+type cmdProcess struct {
+	opt      *commandOption
+	path     string
+	args     []string
+	lock     sync.Mutex
+	ctx      context.Context
+	name     string
+	cmd      *osexec.Cmd
+	exited   chan struct{}
+	stopping bool
+	killed   bool
+}
+
+// Command creates a process running the command in a process group of its own, every run
+// of the process starts the command again. Stop sends the stop signal to the process group
+// and kills it after the stop timeout, the process group is killed as well once the command
+// exited. Run returns an ExitError if the command failed
+func Command(path string, args []string, fs ...CommandOptionFunc) waitprocess.Process {
+	p := &cmdProcess{
+		opt:  newCommandOption(fs...),
+		path: path,
+		args: args,
+	}
+
+	// the prefixes are aligned to the names known before the commands run
+	if p.opt.name != "" {
+		p.register(p.opt.name)
+	}
+
+	return p
+}
+
+// register registers the name of the command with the outputs it writes to
+func (p *cmdProcess) register(name string) {
+	for _, s := range p.opt.sinks {
+		if out, ok := s.(*Output); ok {
+			out.register(name)
+		}
+	}
+}
+
+// SetContext is called before every run, the process is not stopping anymore unless ctx is
+// done, the run is stopped once ctx is done. the name of the command is the name the process
+// is registered with, unless it's set by WithName
+func (p *cmdProcess) SetContext(ctx context.Context) {
+	name := p.opt.name
+	if name == "" {
+		if registered, ok := waitprocess.ProcessName(ctx); ok {
+			name = registered
+		} else {
+			name = filepath.Base(p.path)
+		}
+		p.register(name)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.ctx = ctx
+	p.name = name
+	p.stopping = false
+	p.killed = false
+}
+
+func (p *cmdProcess) Run() error {
+	p.lock.Lock()
+	ctx := p.ctx
+	// a stop before SetContext only cancelled the context of the run
+	if p.stopping || (ctx != nil && ctx.Err() != nil) {
+		p.lock.Unlock()
+		return nil
+	}
+
+	cmd := osexec.Command(p.path, p.args...)
+	cmd.Dir = p.opt.dir
+	cmd.Env = p.opt.env
+	cmd.Stdout = p.opt.stdout
+	cmd.Stderr = p.opt.stderr
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	var capture *capture
+	var stdout, stderr *lineWriter
+	if len(p.opt.sinks) > 0 {
+		capture = newCapture(p.name, p.opt.sinks, max(p.opt.bufferLines, 1))
+		stdout, stderr = capture.writer("stdout"), capture.writer("stderr")
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		defer func() {
+			stdout.close()
+			stderr.close()
+			capture.close()
+		}()
+	}
+
+	if err := cmd.Start(); err != nil {
+		p.lock.Unlock()
+		return err
+	}
+
+	exited := make(chan struct{})
+	p.cmd = cmd
+	p.exited = exited
+	p.lock.Unlock()
+
+	// the command is stopped with the context of its run, but not the command of a later run
+	if ctx != nil {
+		stop := context.AfterFunc(ctx, func() {
+			p.lock.Lock()
+			current := p.cmd == cmd
+			if current {
+				p.stopping = true
+			}
+			p.lock.Unlock()
+
+			if current {
+				p.stopCmd(cmd, exited)
+			}
+		})
+		defer stop()
+	}
+
+	err := cmd.Wait()
+	close(exited)
+	// children of the command must not outlive it
+	signalGroup(cmd, syscall.SIGKILL)
+
+	p.lock.Lock()
+	stopping, killed := p.stopping, p.killed
+	p.cmd = nil
+	p.lock.Unlock()
+
+	return p.exitError(cmd, err, stopping, killed)
+}
+
+func (p *cmdProcess) Stop() {
+	p.lock.Lock()
+	p.stopping = true
+	cmd, exited := p.cmd, p.exited
+	p.lock.Unlock()
+
+	if cmd != nil {
+		p.stopCmd(cmd, exited)
+	}
+}
+
+// stopCmd sends the stop signal to the process group of cmd, and kills it after the stop timeout
+func (p *cmdProcess) stopCmd(cmd *osexec.Cmd, exited chan struct{}) {
+	signalGroup(cmd, p.opt.stopSignal)
+
+	go func() {
+		timer := time.NewTimer(p.opt.stopTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-exited:
+		case <-timer.C:
+			p.lock.Lock()
+			p.killed = true
+			p.lock.Unlock()
+			signalGroup(cmd, syscall.SIGKILL)
+		}
+	}()
+}
+
+// exitError returns the error of the run, an exit caused by the stop signal is no error
+func (p *cmdProcess) exitError(cmd *osexec.Cmd, err error, stopping, killed bool) error {
+	state := cmd.ProcessState
+	if state == nil {
+		return err
+	}
+
+	if errors.Is(err, osexec.ErrWaitDelay) && state.Success() {
+		return nil
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	exitErr := &ExitError{
+		Command: p.path,
+		Code:    state.ExitCode(),
+		Killed:  killed,
+		Err:     err,
+	}
+
+	if sig, ok := exitSignal(state); ok {
+		if stopping && !killed && sig == p.opt.stopSignal {
+			return nil
+		}
+		exitErr.Signal = sig
+	}
+
+	return exitErr
+}
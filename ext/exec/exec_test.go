@@ -0,0 +1,286 @@
+//go:build unix
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func sh(script string, fs ...CommandOptionFunc) *cmdProcess {
+	return Command("sh", []string{"-c", script}, fs...).(*cmdProcess)
+}
+
+// runAsync runs the process and waits for the command to be started
+func runAsync(t *testing.T, p *cmdProcess) <-chan error {
+	result := make(chan error, 1)
+	p.SetContext(context.Background())
+	go func() {
+		result <- p.Run()
+	}()
+
+	assert.Eventually(t, func() bool {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.cmd != nil
+	}, time.Second*5, time.Millisecond)
+	return result
+}
+
+func wait(t *testing.T, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Second * 5):
+		t.Fatal("the command didn't exit")
+		return nil
+	}
+}
+
+// alive returns true if the process exists and is not a zombie
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
+
+func TestCommand(t *testing.T) {
+	t.Run("exit-ok", func(t *testing.T) {
+		p := sh("exit 0")
+		p.SetContext(context.Background())
+		assert.Nil(t, p.Run())
+	})
+
+	t.Run("exit-code", func(t *testing.T) {
+		p := sh("exit 3")
+		p.SetContext(context.Background())
+		err := p.Run()
+
+		var exitErr *ExitError
+		assert.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, 3, exitErr.Code)
+		assert.Nil(t, exitErr.Signal)
+		assert.False(t, exitErr.Killed)
+		assert.EqualError(t, err, "command sh exited with code 3")
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		p := Command(filepath.Join(t.TempDir(), "missing"), nil)
+		p.SetContext(context.Background())
+		assert.NotNil(t, p.Run())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		p := sh("sleep 10")
+		result := runAsync(t, p)
+
+		p.Stop()
+		assert.Nil(t, wait(t, result))
+	})
+
+	t.Run("stop-signal", func(t *testing.T) {
+		p := sh("sleep 10", WithStopSignal(syscall.SIGINT))
+		result := runAsync(t, p)
+
+		p.Stop()
+		assert.Nil(t, wait(t, result))
+	})
+
+	t.Run("killed-after-stop-timeout", func(t *testing.T) {
+		p := sh("trap '' TERM; sleep 10", WithStopTimeout(time.Millisecond*100))
+		result := runAsync(t, p)
+
+		start := time.Now()
+		p.Stop()
+		err := wait(t, result)
+		assert.Less(t, time.Since(start), time.Second*5)
+
+		var exitErr *ExitError
+		assert.True(t, errors.As(err, &exitErr))
+		assert.True(t, exitErr.Killed)
+		assert.Equal(t, syscall.SIGKILL, exitErr.Signal)
+		assert.Equal(t, -1, exitErr.Code)
+		assert.EqualError(t, err, "command sh killed by signal killed after stop timeout")
+	})
+
+	t.Run("killed-by-signal", func(t *testing.T) {
+		p := sh("kill -USR1 $$")
+		p.SetContext(context.Background())
+		err := p.Run()
+
+		var exitErr *ExitError
+		assert.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, syscall.SIGUSR1, exitErr.Signal)
+		assert.False(t, exitErr.Killed)
+	})
+
+	t.Run("process-group-killed-on-exit", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		p := sh("sleep 30 >/dev/null 2>&1 & echo $!", WithStdout(out))
+		p.SetContext(context.Background())
+		assert.Nil(t, p.Run())
+
+		pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+		assert.Nil(t, err)
+		assert.Eventually(t, func() bool {
+			return !alive(pid)
+		}, time.Second*5, time.Millisecond*10)
+	})
+
+	t.Run("process-group-stopped", func(t *testing.T) {
+		pidFile := filepath.Join(t.TempDir(), "pid")
+		p := sh("trap '' TERM; sleep 30 & echo $! > "+pidFile+"; wait", WithStopTimeout(time.Millisecond*100))
+		result := runAsync(t, p)
+
+		var pid int
+		assert.Eventually(t, func() bool {
+			data, _ := os.ReadFile(pidFile)
+			pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+			return pid > 0
+		}, time.Second*5, time.Millisecond*10)
+
+		p.Stop()
+		wait(t, result)
+		assert.Eventually(t, func() bool {
+			return !alive(pid)
+		}, time.Second*5, time.Millisecond*10)
+	})
+
+	t.Run("stop-before-run", func(t *testing.T) {
+		p := sh("sleep 10")
+		p.SetContext(context.Background())
+		p.Stop()
+
+		start := time.Now()
+		assert.Nil(t, p.Run())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("stop-before-set-context", func(t *testing.T) {
+		// the process is stopped before its run started, only the context of the run is cancelled
+		ctx, cancel := context.WithCancel(context.Background())
+		p := sh("sleep 10")
+		p.Stop()
+		cancel()
+		p.SetContext(ctx)
+
+		start := time.Now()
+		assert.Nil(t, p.Run())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("context-cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := sh("sleep 10")
+		p.SetContext(ctx)
+		result := make(chan error, 1)
+		go func() {
+			result <- p.Run()
+		}()
+
+		assert.Eventually(t, func() bool {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			return p.cmd != nil
+		}, time.Second*5, time.Millisecond)
+		cancel()
+		assert.Nil(t, wait(t, result))
+	})
+
+	t.Run("run-again", func(t *testing.T) {
+		runs := filepath.Join(t.TempDir(), "runs")
+		p := Command("sh", []string{"-c", `echo run >> "$0"; exec sleep 10`, runs}).(*cmdProcess)
+
+		for i := 1; i <= 2; i++ {
+			// SetContext resets the stop of the previous run
+			result := runAsync(t, p)
+			assert.Eventually(t, func() bool {
+				data, _ := os.ReadFile(runs)
+				return strings.Count(string(data), "run") == i
+			}, time.Second*5, time.Millisecond*10)
+
+			p.Stop()
+			assert.Nil(t, wait(t, result))
+		}
+	})
+
+	t.Run("restart-process", func(t *testing.T) {
+		wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(waitprocess.NewNopLogger()))
+		wp.RegisterProcess("sleep", Command("sleep", []string{"10"}))
+		assert.Nil(t, wp.Start())
+		assert.Eventually(t, func() bool {
+			return wp.Snapshot()[0].State == waitprocess.ProcessRunning
+		}, time.Second*5, time.Millisecond*10)
+
+		assert.Nil(t, wp.RestartProcess("sleep"))
+		assert.Eventually(t, func() bool {
+			status := wp.Snapshot()[0]
+			return status.Restarts == 1 && status.State == waitprocess.ProcessRunning
+		}, time.Second*5, time.Millisecond*10)
+
+		time.Sleep(time.Millisecond * 100)
+		assert.False(t, wp.Stopped())
+		assert.Nil(t, wp.Shutdown(time.Second*5))
+	})
+
+	t.Run("output-name", func(t *testing.T) {
+		buf, logBuf := &bytes.Buffer{}, &bytes.Buffer{}
+		wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(waitprocess.NewSlogLogger(slog.NewTextHandler(logBuf, nil))))
+		out := NewOutput(buf, WithColor(true))
+		for _, name := range []string{"web", "worker"} {
+			wp.RegisterProcess(name, Command("sh", []string{"-c", "echo hello; exec sleep 10"},
+				WithOutput(out),
+				WithLogOutput(wp.Logger()),
+			))
+		}
+
+		assert.Nil(t, wp.Start())
+		assert.Eventually(t, func() bool {
+			snapshot := wp.Snapshot()
+			return snapshot[0].State == waitprocess.ProcessRunning && snapshot[1].State == waitprocess.ProcessRunning
+		}, time.Second*5, time.Millisecond*10)
+		assert.Nil(t, wp.Shutdown(time.Second*5))
+
+		// the commands are named after their registration, not after the path
+		assert.NotEqual(t, out.colors["web"], out.colors["worker"])
+		assert.Contains(t, buf.String(), "web")
+		assert.Contains(t, buf.String(), "worker |\x1b[0m hello\n")
+		assert.NotContains(t, buf.String(), "sh ")
+		assert.Contains(t, logBuf.String(), "msg=hello proc=web stream=stdout")
+		assert.Contains(t, logBuf.String(), "msg=hello proc=worker stream=stdout")
+	})
+
+	t.Run("output-with-name", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		out := NewOutput(buf)
+		p := sh("echo hello", WithName("greeter"), WithOutput(out))
+		p.SetContext(context.Background())
+		assert.Nil(t, p.Run())
+		assert.Equal(t, "greeter | hello\n", buf.String())
+
+		buf.Reset()
+		p = sh("echo hello", WithOutput(NewOutput(buf)))
+		p.SetContext(context.Background())
+		assert.Nil(t, p.Run())
+		assert.Equal(t, "sh | hello\n", buf.String())
+	})
+}
@@ -0,0 +1,232 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/siriusa51/waitprocess/v2"
+	"io"
+	"sync"
+)
+
+const (
+	// maxLineLength is the longest line captured, longer lines are split
+	maxLineLength = 64 * 1024
+	// defaultOutputBuffer is the number of lines buffered by default before lines are dropped
+	defaultOutputBuffer = 1024
+)
+
+// colors are the ANSI colors of the prefixes, assigned to the commands in turn
+var colors = []int{36, 33, 32, 35, 34, 31, 96, 93, 92, 95, 94, 91}
+
+// sink receives the captured lines of the commands
+type sink interface {
+	writeLine(name, stream string, line []byte)
+}
+
+// logSink writes every line to the logger with the name of the command and the stream as fields
+type logSink struct {
+	log waitprocess.Logger
+}
+
+func (s *logSink) writeLine(name, stream string, line []byte) {
+	s.log.WithField("proc", name).WithField("stream", stream).Info(string(line))
+}
+
+type outputOption struct {
+	color bool
+}
+
+type OutputOptionFunc func(*outputOption)
+
+// WithColor colors the prefix of every command, disabled by default
+func WithColor(color bool) OutputOptionFunc {
+	return func(opt *outputOption) {
+		opt.color = color
+	}
+}
+
+// Output multiplexes the output of several commands to a single writer, every line is
+// prefixed with the name of the command and written at once, so lines of different
+// commands never interleave
+type Output struct {
+	opt    outputOption
+	lock   sync.Mutex
+	w      io.Writer
+	width  int
+	colors map[string]int
+}
+
+// NewOutput creates an Output writing to w, commands write to it with WithOutput
+func NewOutput(w io.Writer, fs ...OutputOptionFunc) *Output {
+	out := &Output{
+		w:      w,
+		colors: map[string]int{},
+	}
+
+	for _, f := range fs {
+		f(&out.opt)
+	}
+	return out
+}
+
+// register assigns a color to the command and aligns the prefixes to its name
+func (o *Output) register(name string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if _, ok := o.colors[name]; !ok {
+		o.colors[name] = colors[len(o.colors)%len(colors)]
+	}
+	o.width = max(o.width, len(name))
+}
+
+func (o *Output) writeLine(name, _ string, line []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	buf := bytes.Buffer{}
+	if o.opt.color {
+		fmt.Fprintf(&buf, "\x1b[%dm%-*s |\x1b[0m ", o.colors[name], o.width, name)
+	} else {
+		fmt.Fprintf(&buf, "%-*s | ", o.width, name)
+	}
+	buf.Write(line)
+	buf.WriteByte('\n')
+
+	_, _ = o.w.Write(buf.Bytes())
+}
+
+type capturedLine struct {
+	stream string
+	line   []byte
+	// dropped is the number of lines dropped before the line
+	dropped int64
+}
+
+// capture writes the lines of both streams of a run to the sinks in order. lines are
+// buffered and written by a goroutine of their own, a slow sink doesn't block the command,
+// lines are dropped when the buffer is full
+type capture struct {
+	name    string
+	sinks   []sink
+	lock    sync.Mutex
+	lines   chan capturedLine
+	dropped int64
+	done    chan struct{}
+}
+
+func newCapture(name string, sinks []sink, size int) *capture {
+	c := &capture{
+		name:  name,
+		sinks: sinks,
+		lines: make(chan capturedLine, size),
+		done:  make(chan struct{}),
+	}
+
+	go c.drain()
+	return c
+}
+
+// push buffers the line, the dropped lines are reported before the next line buffered
+func (c *capture) push(stream string, line []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	select {
+	case c.lines <- capturedLine{stream: stream, line: line, dropped: c.dropped}:
+		c.dropped = 0
+	default:
+		c.dropped++
+	}
+}
+
+func (c *capture) drain() {
+	defer close(c.done)
+
+	stream := ""
+	for l := range c.lines {
+		c.reportDropped(l.stream, l.dropped)
+		c.write(l.stream, l.line)
+		stream = l.stream
+	}
+
+	c.lock.Lock()
+	dropped := c.dropped
+	c.lock.Unlock()
+	c.reportDropped(stream, dropped)
+}
+
+func (c *capture) reportDropped(stream string, dropped int64) {
+	switch {
+	case dropped == 1:
+		c.write(stream, []byte("... 1 line dropped"))
+	case dropped > 1:
+		c.write(stream, []byte(fmt.Sprintf("... %d lines dropped", dropped)))
+	}
+}
+
+func (c *capture) write(stream string, line []byte) {
+	for _, s := range c.sinks {
+		s.writeLine(c.name, stream, line)
+	}
+}
+
+// close waits for the buffered lines to be written, the writers of the streams must be closed first
+func (c *capture) close() {
+	close(c.lines)
+	<-c.done
+}
+
+// lineWriter splits the output of a stream into lines, a partial line is kept until it's
+// completed or the stream is closed
+type lineWriter struct {
+	capture *capture
+	stream  string
+	lock    sync.Mutex
+	buf     []byte
+}
+
+func (c *capture) writer(stream string) *lineWriter {
+	return &lineWriter{capture: c, stream: stream}
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.push(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+
+	for len(w.buf) > maxLineLength {
+		w.push(w.buf[:maxLineLength])
+		w.buf = w.buf[maxLineLength:]
+	}
+
+	// don't keep the consumed part of a large write alive
+	w.buf = append([]byte(nil), w.buf...)
+	return len(b), nil
+}
+
+// close flushes the partial line of the stream
+func (w *lineWriter) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.buf) > 0 {
+		w.push(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) push(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	w.capture.push(w.stream, append([]byte(nil), line...))
+}
@@ -0,0 +1,179 @@
+package exec
+
+import (
+	"bytes"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordsink records the lines as "name stream: line", once entered is received it blocks
+// until gate is closed
+type recordsink struct {
+	lock    sync.Mutex
+	lines   []string
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (s *recordsink) writeLine(name, stream string, line []byte) {
+	if s.gate != nil {
+		select {
+		case s.entered <- struct{}{}:
+		default:
+		}
+		<-s.gate
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lines = append(s.lines, name+" "+stream+": "+string(line))
+}
+
+func (s *recordsink) getLines() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.lines...)
+}
+
+func TestLineWriter(t *testing.T) {
+	t.Run("lines", func(t *testing.T) {
+		rec := &recordsink{}
+		c := newCapture("cmd", []sink{rec}, 10)
+		stdout, stderr := c.writer("stdout"), c.writer("stderr")
+
+		stdout.Write([]byte("a\nb"))
+		stdout.Write([]byte("c\r\n\n"))
+		stderr.Write([]byte("err\npartial"))
+		stdout.close()
+		stderr.close()
+		c.close()
+
+		assert.Equal(t, []string{
+			"cmd stdout: a",
+			"cmd stdout: bc",
+			"cmd stdout: ",
+			"cmd stderr: err",
+			"cmd stderr: partial",
+		}, rec.getLines())
+	})
+
+	t.Run("long-line", func(t *testing.T) {
+		rec := &recordsink{}
+		c := newCapture("cmd", []sink{rec}, 10)
+		w := c.writer("stdout")
+
+		n, err := w.Write(bytes.Repeat([]byte("x"), maxLineLength*2+10))
+		assert.Nil(t, err)
+		assert.Equal(t, maxLineLength*2+10, n)
+		w.Write([]byte("y\n"))
+		w.close()
+		c.close()
+
+		lines := rec.getLines()
+		assert.Len(t, lines, 3)
+		assert.Equal(t, "cmd stdout: "+strings.Repeat("x", maxLineLength), lines[0])
+		assert.Equal(t, "cmd stdout: "+strings.Repeat("x", maxLineLength), lines[1])
+		assert.Equal(t, "cmd stdout: "+strings.Repeat("x", 10)+"y", lines[2])
+	})
+}
+
+func TestCapture(t *testing.T) {
+	t.Run("dropped", func(t *testing.T) {
+		rec := &recordsink{entered: make(chan struct{}), gate: make(chan struct{})}
+		c := newCapture("cmd", []sink{rec}, 1)
+		w := c.writer("stdout")
+
+		// the sink blocks on the first line, the second one is buffered and the others dropped
+		w.Write([]byte("1\n"))
+		<-rec.entered
+		w.Write([]byte("2\n3\n4\n5\n"))
+
+		close(rec.gate)
+		assert.Eventually(t, func() bool {
+			return len(rec.getLines()) == 2
+		}, time.Second*5, time.Millisecond)
+
+		w.Write([]byte("6\n7\n"))
+		w.close()
+		c.close()
+
+		lines := rec.getLines()
+		assert.Equal(t, []string{"cmd stdout: 1", "cmd stdout: 2", "cmd stdout: ... 3 lines dropped", "cmd stdout: 6"}, lines[:4])
+		// the last line is dropped if the drain didn't keep up, it's then reported on close
+		if len(lines) == 5 {
+			assert.Equal(t, "cmd stdout: 7", lines[4])
+		} else {
+			assert.Equal(t, []string{"cmd stdout: ... 1 line dropped"}, lines[4:])
+		}
+	})
+
+	t.Run("dropped-on-close", func(t *testing.T) {
+		rec := &recordsink{entered: make(chan struct{}), gate: make(chan struct{})}
+		c := newCapture("cmd", []sink{rec}, 1)
+		w := c.writer("stderr")
+
+		w.Write([]byte("1\n"))
+		<-rec.entered
+		w.Write([]byte("2\n3\n4"))
+		w.close()
+
+		close(rec.gate)
+		c.close()
+		assert.Equal(t, []string{"cmd stderr: 1", "cmd stderr: 2", "cmd stderr: ... 2 lines dropped"}, rec.getLines())
+	})
+
+	t.Run("sinks", func(t *testing.T) {
+		rec1, rec2 := &recordsink{}, &recordsink{}
+		c := newCapture("cmd", []sink{rec1, rec2}, 10)
+		c.push("stdout", []byte("line"))
+		c.close()
+
+		assert.Equal(t, []string{"cmd stdout: line"}, rec1.getLines())
+		assert.Equal(t, []string{"cmd stdout: line"}, rec2.getLines())
+	})
+}
+
+func TestOutput(t *testing.T) {
+	t.Run("prefix", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		out := NewOutput(buf)
+		out.register("web")
+		out.register("worker")
+
+		out.writeLine("web", "stdout", []byte("hello"))
+		out.writeLine("worker", "stderr", []byte("world"))
+		assert.Equal(t, "web    | hello\nworker | world\n", buf.String())
+	})
+
+	t.Run("color", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		out := NewOutput(buf, WithColor(true))
+		out.register("a")
+		out.register("bb")
+		out.register("a")
+
+		out.writeLine("a", "stdout", []byte("1"))
+		out.writeLine("bb", "stdout", []byte("2"))
+		assert.Equal(t, "\x1b[36ma  |\x1b[0m 1\n\x1b[33mbb |\x1b[0m 2\n", buf.String())
+	})
+
+	t.Run("colors-cycle", func(t *testing.T) {
+		out := NewOutput(&bytes.Buffer{}, WithColor(true))
+		for i := 0; i <= len(colors); i++ {
+			out.register(strings.Repeat("x", i+1))
+		}
+		assert.Equal(t, colors[0], out.colors[strings.Repeat("x", len(colors)+1)])
+	})
+}
+
+func TestLogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := &logSink{log: waitprocess.NewSlogLogger(slog.NewTextHandler(buf, nil))}
+	log.writeLine("web", "stderr", []byte("hello world"))
+	assert.Contains(t, buf.String(), `level=INFO msg="hello world" proc=web stream=stderr`)
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package exec
+
+import (
+	"os"
+	osexec "os/exec"
+)
+
+// process groups are not supported, only the command itself is signalled
+func setProcessGroup(cmd *osexec.Cmd) {}
+
+func signalGroup(cmd *osexec.Cmd, sig os.Signal) {
+	if sig == os.Kill {
+		cmd.Process.Kill()
+		return
+	}
+	cmd.Process.Signal(sig)
+}
+
+func exitSignal(state *os.ProcessState) (os.Signal, bool) {
+	return nil, false
+}
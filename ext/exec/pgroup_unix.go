@@ -0,0 +1,30 @@
+//go:build unix
+
+package exec
+
+import (
+	"os"
+	osexec "os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *osexec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup sends the signal to the process group of the command, the group id is the pid
+func signalGroup(cmd *osexec.Cmd, sig os.Signal) {
+	if sysSig, ok := sig.(syscall.Signal); ok {
+		syscall.Kill(-cmd.Process.Pid, sysSig)
+		return
+	}
+	cmd.Process.Signal(sig)
+}
+
+func exitSignal(state *os.ProcessState) (os.Signal, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return nil, false
+	}
+	return status.Signal(), true
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"github.com/siriusa51/waitprocess/v2"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 )
 
@@ -12,8 +15,10 @@ type httpServerOption struct {
 	wp            *waitprocess.WaitProcess
 	timeout       time.Duration
 	name          string
-	log           *logrus.Entry
+	log           waitprocess.Logger
 	afterStopHook func()
+	procOpts      []waitprocess.ProcessOption
+	network       string
 }
 
 type HttpServerOptionFunc func(*httpServerOption)
@@ -21,9 +26,10 @@ type HttpServerOptionFunc func(*httpServerOption)
 func newHTTPServerOption(opts ...HttpServerOptionFunc) *httpServerOption {
 	opt := &httpServerOption{
 		name:    "http_srv",
+		network: "tcp",
 		timeout: time.Second * 15,
 		wp:      waitprocess.Default(),
-		log:     logrus.WithField("pkg", "waitprocess/http_srv"),
+		log:     waitprocess.NewLogrusLogger(logrus.WithField("pkg", "waitprocess/http_srv")),
 	}
 
 	for _, o := range opts {
@@ -50,40 +56,174 @@ func WithWaitProcess(wp *waitprocess.WaitProcess) HttpServerOptionFunc {
 	}
 }
 
+// WithLogger sets the logger of the server, e.g. waitprocess.NewSlogLogger
+func WithLogger(log waitprocess.Logger) HttpServerOptionFunc {
+	return func(opt *httpServerOption) {
+		opt.log = log
+	}
+}
+
+// WithNetwork sets the network the server listens on, "tcp" by default. with "unix" addr is
+// the socket path, a stale socket is removed and the socket is only accessible by its owner
+func WithNetwork(network string) HttpServerOptionFunc {
+	return func(opt *httpServerOption) {
+		opt.network = network
+	}
+}
+
+// WithAfterStopHook sets a hook run once the server is stopped for good, it's not run when
+// the server is restarted in place
 func WithAfterStopHook(f func()) HttpServerOptionFunc {
 	return func(opt *httpServerOption) {
 		opt.afterStopHook = f
 	}
 }
 
+// WithProcessOptions sets the options the server is registered with, e.g. waitprocess.DependsOn
+func WithProcessOptions(opts ...waitprocess.ProcessOption) HttpServerOptionFunc {
+	return func(opt *httpServerOption) {
+		opt.procOpts = append(opt.procOpts, opts...)
+	}
+}
+
+// RegisterHttpSrv registers a server serving handler on addr, every run of the process listens
+// on addr with a new http.Server, so the process can be restarted
 func RegisterHttpSrv(addr string, handler http.Handler, fs ...HttpServerOptionFunc) *waitprocess.WaitProcess {
 	opt := newHTTPServerOption(fs...)
 
-	srv := http.Server{
-		Addr:    addr,
-		Handler: handler,
+	proc := &httpServerProcess{
+		opt:     opt,
+		addr:    addr,
+		handler: handler,
 	}
 
-	return opt.wp.RegisterProcess(opt.name, waitprocess.RunWithStopFunc(
-		func() error {
-			err := srv.ListenAndServe()
-			if err != nil && err != http.ErrServerClosed {
-				return err
-			}
+	return opt.wp.RegisterProcess(opt.name, proc, opt.procOpts...)
+}
+
+type httpServerProcess struct {
+	opt      *httpServerOption
+	addr     string
+	handler  http.Handler
+	ready    func()
+	lock     sync.Mutex
+	ctx      context.Context
+	srv      *http.Server
+	stopping bool
+}
 
-			return nil
-		},
-		func() {
-			ctx, cancel := context.WithTimeout(context.Background(), opt.timeout)
-			defer cancel()
+// NotifyReady implements waitprocess.ReadyNotifier, the server is ready once it listens on addr
+func (p *httpServerProcess) NotifyReady(ready func()) {
+	p.ready = ready
+}
 
-			if err := srv.Shutdown(ctx); err != nil {
-				opt.log.WithError(err).Error("http.Server.Shutdown() error")
+// SetContext is called before every run, the server is not stopping anymore
+func (p *httpServerProcess) SetContext(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.ctx = ctx
+	p.stopping = false
+}
+
+func (p *httpServerProcess) Run() error {
+	p.lock.Lock()
+	if p.stopping {
+		p.lock.Unlock()
+		return nil
+	}
+
+	ln, err := listen(p.opt.network, p.addr)
+	if err != nil {
+		p.lock.Unlock()
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    p.addr,
+		Handler: p.handler,
+	}
+	p.srv = srv
+	ctx := p.ctx
+	p.lock.Unlock()
+
+	// the context of the run is cancelled when it's stopped, even if Stop raced with the start
+	if ctx != nil {
+		stopShutdown := context.AfterFunc(ctx, func() {
+			p.lock.Lock()
+			current := p.srv == srv
+			if current {
+				p.srv = nil
 			}
+			p.lock.Unlock()
 
-			if opt.afterStopHook != nil {
-				opt.afterStopHook()
+			if current {
+				p.shutdown(srv)
 			}
-		},
-	))
+		})
+		defer stopShutdown()
+	}
+
+	if p.ready != nil {
+		p.ready()
+	}
+
+	err = srv.Serve(ln)
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+func (p *httpServerProcess) Stop() {
+	p.lock.Lock()
+	p.stopping = true
+	srv := p.srv
+	p.srv = nil
+	ctx := p.ctx
+	p.lock.Unlock()
+
+	if srv != nil {
+		p.shutdown(srv)
+	}
+
+	// the server runs again after a restart
+	if p.opt.afterStopHook != nil && (ctx == nil || !waitprocess.RestartRequested(ctx)) {
+		p.opt.afterStopHook()
+	}
+}
+
+func (p *httpServerProcess) shutdown(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.opt.timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		p.opt.log.WithError(err).Error("http.Server.Shutdown() error")
+	}
+}
+
+func listen(network, addr string) (net.Listener, error) {
+	if network != "unix" {
+		if addr == "" {
+			addr = ":http"
+		}
+		return net.Listen(network, addr)
+	}
+
+	if info, err := os.Stat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(addr, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
 }
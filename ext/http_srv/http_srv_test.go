@@ -0,0 +1,98 @@
+package http_srv
+
+import (
+	"context"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func unixClient(sock string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		},
+	}
+}
+
+func get(client *http.Client) (string, error) {
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestRegisterHttpSrv(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	t.Run("case-unix-socket", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "srv.sock")
+		wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(waitprocess.NewNopLogger()))
+		RegisterHttpSrv(sock, handler, WithWaitProcess(wp), WithNetwork("unix"), WithLogger(waitprocess.NewNopLogger()))
+
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+
+		info, err := os.Stat(sock)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		body, err := get(unixClient(sock))
+		assert.Nil(t, err)
+		assert.Equal(t, "hello", body)
+
+		assert.Nil(t, wp.Shutdown(time.Second*5))
+	})
+
+	t.Run("case-restart", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "srv.sock")
+		var stops int32
+		wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(waitprocess.NewNopLogger()))
+		RegisterHttpSrv(sock, handler,
+			WithWaitProcess(wp),
+			WithNetwork("unix"),
+			WithLogger(waitprocess.NewNopLogger()),
+			WithAfterStopHook(func() { atomic.AddInt32(&stops, 1) }),
+		)
+
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+		client := unixClient(sock)
+
+		for i := 1; i <= 2; i++ {
+			assert.Nil(t, wp.RestartProcess("http_srv"))
+			assert.Eventually(t, func() bool {
+				status := wp.Snapshot()[0]
+				return status.Restarts == i && status.State == waitprocess.ProcessRunning
+			}, time.Second*5, time.Millisecond*10)
+
+			// the restarted server serves again and the waitprocess keeps running
+			assert.Eventually(t, func() bool {
+				body, err := get(client)
+				return err == nil && body == "hello"
+			}, time.Second*5, time.Millisecond*10)
+			assert.False(t, wp.Stopped())
+		}
+
+		assert.Equal(t, waitprocess.StopReasonNone, wp.StopReason().Kind)
+		assert.Nil(t, wp.Shutdown(time.Second*5))
+		// the hook only runs on the final stop
+		assert.Equal(t, int32(1), atomic.LoadInt32(&stops))
+	})
+
+}
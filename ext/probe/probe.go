@@ -0,0 +1,259 @@
+package probe
+
+import (
+	"encoding/json"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/siriusa51/waitprocess/v2/ext/http_srv"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+type probeOption struct {
+	wp               *waitprocess.WaitProcess
+	name             string
+	timeout          time.Duration
+	log              waitprocess.Logger
+	failureThreshold int
+	staleAfter       time.Duration
+	procOpts         []waitprocess.ProcessOption
+}
+
+type ProbeOptionFunc func(*probeOption)
+
+func newProbeOption(opts ...ProbeOptionFunc) *probeOption {
+	opt := &probeOption{
+		name:             "probe",
+		timeout:          time.Second * 15,
+		wp:               waitprocess.Default(),
+		log:              waitprocess.NewLogrusLogger(logrus.WithField("pkg", "waitprocess/probe")),
+		failureThreshold: 1,
+		staleAfter:       time.Minute,
+	}
+
+	for _, o := range opts {
+		o(opt)
+	}
+	return opt
+}
+
+// WithName sets the name the probe server is registered with, "probe" by default
+func WithName(name string) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.name = name
+	}
+}
+
+// WithTimeout sets how long the probe server waits for pending requests when it stops
+func WithTimeout(timeout time.Duration) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.timeout = timeout
+	}
+}
+
+// WithWaitProcess sets the waitprocess that is probed and the probe server is registered to
+func WithWaitProcess(wp *waitprocess.WaitProcess) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.wp = wp
+	}
+}
+
+// WithLogger sets the logger of the probe server
+func WithLogger(log waitprocess.Logger) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.log = log
+	}
+}
+
+// WithFailureThreshold sets the number of consecutive failed health checks that fails the
+// liveness probe, 1 by default
+func WithFailureThreshold(threshold int) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.failureThreshold = threshold
+	}
+}
+
+// WithStaleAfter sets how long a running process implementing waitprocess.HealthChecker may
+// go without a finished health check before it is considered hung, 1 minute by default.
+// it must be longer than the health check interval, zero disables it
+func WithStaleAfter(d time.Duration) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.staleAfter = d
+	}
+}
+
+// WithProcessOptions sets the options the probe server is registered with
+func WithProcessOptions(opts ...waitprocess.ProcessOption) ProbeOptionFunc {
+	return func(opt *probeOption) {
+		opt.procOpts = append(opt.procOpts, opts...)
+	}
+}
+
+// Handler returns the probe handler of the waitprocess, it serves:
+//
+//	GET /startupz  passes once all processes have been ready
+//	GET /readyz    passes while all processes are ready, fails as soon as the waitprocess stops.
+//	               processes that exited without stopping the waitprocess pass
+//	GET /livez     fails when a process fails its health checks or its health check hangs
+//
+// the probes answer 200 or 503 with the detail of every process in the JSON body
+func Handler(fs ...ProbeOptionFunc) http.Handler {
+	p := &prober{opt: newProbeOption(fs...)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /startupz", p.serve(p.startup))
+	mux.HandleFunc("GET /readyz", p.serve(p.readiness))
+	mux.HandleFunc("GET /livez", p.serve(p.liveness))
+	return mux
+}
+
+// RegisterProbeSrv registers a server serving the probe handler
+func RegisterProbeSrv(addr string, fs ...ProbeOptionFunc) *waitprocess.WaitProcess {
+	opt := newProbeOption(fs...)
+
+	return http_srv.RegisterHttpSrv(addr, Handler(fs...),
+		http_srv.WithName(opt.name),
+		http_srv.WithTimeout(opt.timeout),
+		http_srv.WithWaitProcess(opt.wp),
+		http_srv.WithLogger(opt.log),
+		http_srv.WithProcessOptions(opt.procOpts...),
+	)
+}
+
+type processResult struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type result struct {
+	OK         bool            `json:"ok"`
+	Reason     string          `json:"reason,omitempty"`
+	Processes  []processResult `json:"processes"`
+	StopReason string          `json:"stop_reason,omitempty"`
+}
+
+type prober struct {
+	opt *probeOption
+}
+
+// serve returns a handler answering the result of check
+func (p *prober) serve(check func([]waitprocess.ProcessStatus) result) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := check(p.opt.wp.Snapshot())
+		if reason := p.opt.wp.StopReason(); reason.Kind != waitprocess.StopReasonNone {
+			res.StopReason = reason.String()
+		}
+
+		code := http.StatusOK
+		if !res.OK {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			p.opt.log.WithError(err).Error("Failed to write probe result")
+		}
+	}
+}
+
+// each checks every process with check, which returns an empty reason if the process passes
+func each(snapshot []waitprocess.ProcessStatus, check func(waitprocess.ProcessStatus) string) result {
+	res := result{OK: true, Processes: make([]processResult, 0, len(snapshot))}
+	for _, status := range snapshot {
+		reason := check(status)
+		res.Processes = append(res.Processes, processResult{
+			Name:   status.Name,
+			State:  status.State.String(),
+			OK:     reason == "",
+			Reason: reason,
+		})
+
+		if reason != "" {
+			res.OK = false
+		}
+	}
+
+	return res
+}
+
+func (p *prober) startup(snapshot []waitprocess.ProcessStatus) result {
+	started := isClosed(p.opt.wp.Ready())
+	res := each(snapshot, func(status waitprocess.ProcessStatus) string {
+		if !started && !status.Ready {
+			return "not ready yet"
+		}
+		return ""
+	})
+
+	// processes that were ready and exited since don't fail the startup probe
+	res.OK = started
+	if !started {
+		res.Reason = "not all processes are ready yet"
+	}
+	return res
+}
+
+func (p *prober) readiness(snapshot []waitprocess.ProcessStatus) result {
+	res := each(snapshot, func(status waitprocess.ProcessStatus) string {
+		switch status.State {
+		case waitprocess.ProcessPending, waitprocess.ProcessStarting:
+			return "not ready yet"
+		case waitprocess.ProcessRestarting:
+			return "restarting"
+		case waitprocess.ProcessStopping:
+			return "stopping"
+		case waitprocess.ProcessStopped, waitprocess.ProcessFailed:
+			// the exit of a process that keeps the waitprocess running doesn't affect its readiness
+			if status.ExitPolicy == waitprocess.IgnoreExit ||
+				(status.ExitPolicy == waitprocess.StopGroupOnError && status.State == waitprocess.ProcessStopped) {
+				return ""
+			}
+		}
+
+		if !status.Ready {
+			return "not ready"
+		}
+		return ""
+	})
+
+	if p.opt.wp.StopReason().Kind != waitprocess.StopReasonNone {
+		res.OK = false
+		res.Reason = "waitprocess is stopping"
+	}
+	return res
+}
+
+func (p *prober) liveness(snapshot []waitprocess.ProcessStatus) result {
+	return each(snapshot, func(status waitprocess.ProcessStatus) string {
+		health := status.Health
+		if health == nil || status.State != waitprocess.ProcessRunning {
+			return ""
+		}
+
+		if health.ConsecutiveFailures >= p.opt.failureThreshold && health.LastError != nil {
+			return "health check failed: " + health.LastError.Error()
+		}
+
+		last := health.LastCheck
+		if last.Before(status.StartTime) {
+			last = status.StartTime
+		}
+
+		if p.opt.staleAfter > 0 && time.Since(last) > p.opt.staleAfter {
+			return "no health check finished since " + last.Format(time.RFC3339)
+		}
+		return ""
+	})
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,188 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/siriusa51/waitprocess/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type healthprocess struct {
+	waitprocess.Process
+	check func(ctx context.Context) error
+}
+
+func (hp *healthprocess) Check(ctx context.Context) error {
+	return hp.check(ctx)
+}
+
+func waitCtx() waitprocess.Process {
+	return waitprocess.RunWithCtx(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+}
+
+func newWaitProcess(t *testing.T) *waitprocess.WaitProcess {
+	wp := waitprocess.NewWaitProcess(waitprocess.WithLogger(waitprocess.NewNopLogger()))
+	t.Cleanup(func() {
+		if !wp.Stopped() {
+			wp.Shutdown(time.Second * 5)
+		}
+	})
+	return wp
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, result) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+	var res result
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&res))
+	return rec.Code, res
+}
+
+func TestStartupAndReadiness(t *testing.T) {
+	t.Run("not-ready", func(t *testing.T) {
+		wp := newWaitProcess(t)
+		gate := make(chan struct{})
+		wp.RegisterProcess("worker", waitCtx())
+		wp.RegisterProcess("gated", waitprocess.RunWithReady(func(ctx context.Context, ready func()) error {
+			<-gate
+			ready()
+			<-ctx.Done()
+			return nil
+		}))
+		h := Handler(WithWaitProcess(wp), WithLogger(waitprocess.NewNopLogger()))
+
+		code, res := get(t, h, "/startupz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not all processes are ready yet", res.Reason)
+
+		assert.Nil(t, wp.Start())
+		assert.Eventually(t, func() bool {
+			return wp.Snapshot()[0].State == waitprocess.ProcessRunning
+		}, time.Second*5, time.Millisecond*10)
+
+		code, res = get(t, h, "/startupz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, processResult{Name: "gated", State: "starting", OK: false, Reason: "not ready yet"}, res.Processes[1])
+
+		code, res = get(t, h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, processResult{Name: "worker", State: "running", OK: true}, res.Processes[0])
+		assert.Equal(t, processResult{Name: "gated", State: "starting", OK: false, Reason: "not ready yet"}, res.Processes[1])
+
+		code, _ = get(t, h, "/livez")
+		assert.Equal(t, http.StatusOK, code)
+
+		close(gate)
+		assert.Nil(t, wp.WaitReady(context.Background()))
+		for _, path := range []string{"/startupz", "/readyz", "/livez"} {
+			code, res = get(t, h, path)
+			assert.Equal(t, http.StatusOK, code, path)
+			assert.True(t, res.OK, path)
+		}
+	})
+
+	t.Run("stopping", func(t *testing.T) {
+		wp := newWaitProcess(t)
+		wp.RegisterProcess("worker", waitCtx())
+		h := Handler(WithWaitProcess(wp))
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+
+		wp.Stop()
+		code, res := get(t, h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "waitprocess is stopping", res.Reason)
+		assert.Equal(t, "stopped", res.StopReason)
+
+		// processes that were ready don't fail the startup probe
+		code, _ = get(t, h, "/startupz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Nil(t, wp.Wait(time.Second*5))
+	})
+
+	t.Run("ignore-exit", func(t *testing.T) {
+		wp := newWaitProcess(t)
+		wp.RegisterProcess("worker", waitCtx())
+		// fails before it's ready
+		wp.RegisterProcess("failing", waitprocess.RunWithReady(func(ctx context.Context, ready func()) error {
+			return assert.AnError
+		}), waitprocess.WithExitPolicy(waitprocess.IgnoreExit))
+		wp.RegisterProcess("done", waitprocess.RunWithCtx(func(ctx context.Context) error {
+			return nil
+		}), waitprocess.WithExitPolicy(waitprocess.StopGroupOnError))
+		h := Handler(WithWaitProcess(wp))
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+
+		assert.Eventually(t, func() bool {
+			snapshot := wp.Snapshot()
+			return snapshot[1].State == waitprocess.ProcessFailed && snapshot[2].State == waitprocess.ProcessStopped
+		}, time.Second*5, time.Millisecond*10)
+
+		code, res := get(t, h, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, processResult{Name: "failing", State: "failed", OK: true}, res.Processes[1])
+		assert.Equal(t, processResult{Name: "done", State: "stopped", OK: true}, res.Processes[2])
+		assert.False(t, wp.Stopped())
+	})
+}
+
+func TestLiveness(t *testing.T) {
+	t.Run("health-check-failed", func(t *testing.T) {
+		wp := newWaitProcess(t)
+		wp.RegisterProcess("unhealthy", &healthprocess{Process: waitCtx(), check: func(ctx context.Context) error {
+			return assert.AnError
+		}}, waitprocess.WithHealthCheck(waitprocess.HealthCheck{Interval: time.Millisecond * 10, FailureThreshold: 100}))
+		h := Handler(WithWaitProcess(wp), WithFailureThreshold(3))
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+
+		assert.Eventually(t, func() bool {
+			code, _ := get(t, h, "/livez")
+			return code == http.StatusServiceUnavailable
+		}, time.Second*5, time.Millisecond*10)
+
+		health, ok := wp.Health("unhealthy")
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, health.ConsecutiveFailures, 3)
+
+		_, res := get(t, h, "/livez")
+		assert.False(t, res.OK)
+		assert.Equal(t, "health check failed: "+assert.AnError.Error(), res.Processes[0].Reason)
+
+		// the readiness doesn't depend on the health checks
+		code, _ := get(t, h, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("health-check-stale", func(t *testing.T) {
+		wp := newWaitProcess(t)
+		wp.RegisterProcess("hung", &healthprocess{Process: waitCtx(), check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}, waitprocess.WithHealthCheck(waitprocess.HealthCheck{Interval: time.Millisecond * 10}))
+		h := Handler(WithWaitProcess(wp), WithStaleAfter(time.Millisecond*100))
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.WaitReady(context.Background()))
+
+		code, _ := get(t, h, "/livez")
+		assert.Equal(t, http.StatusOK, code)
+
+		assert.Eventually(t, func() bool {
+			code, _ := get(t, h, "/livez")
+			return code == http.StatusServiceUnavailable
+		}, time.Second*5, time.Millisecond*10)
+
+		_, res := get(t, h, "/livez")
+		assert.True(t, strings.HasPrefix(res.Processes[0].Reason, "no health check finished since"))
+	})
+}
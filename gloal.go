@@ -1,6 +1,9 @@
 package waitprocess
 
 import (
+	"context"
+	"io"
+	"net/http"
 	"os"
 	"sync"
 	"time"
@@ -21,8 +24,23 @@ func Default() *WaitProcess {
 }
 
 // RegisterProcess registers a process with the given name and Process.
-func RegisterProcess(name string, procs Process) *WaitProcess {
-	return Default().RegisterProcess(name, procs)
+func RegisterProcess(name string, procs Process, opts ...ProcessOption) *WaitProcess {
+	return Default().RegisterProcess(name, procs, opts...)
+}
+
+// RemoveProcess stops the process with the given name and removes it from the WaitProcess.
+func RemoveProcess(name string) error {
+	return Default().RemoveProcess(name)
+}
+
+// StopProcess stops the process with the given name without stopping the WaitProcess.
+func StopProcess(name string) error {
+	return Default().StopProcess(name)
+}
+
+// RestartProcess restarts the process with the given name.
+func RestartProcess(name string) error {
+	return Default().RestartProcess(name)
 }
 
 // RegisterSignal registers a signal with the given os.Signal.
@@ -30,9 +48,29 @@ func RegisterSignal(sigs ...os.Signal) *WaitProcess {
 	return Default().RegisterSignal(sigs...)
 }
 
+// RegisterSignalAction maps signals to an action of the WaitProcess.
+func RegisterSignalAction(action SignalAction, sigs ...os.Signal) *WaitProcess {
+	return Default().RegisterSignalAction(action, sigs...)
+}
+
+// RegisterSignalHandler calls the handler when one of the signals is received.
+func RegisterSignalHandler(handler SignalHandler, sigs ...os.Signal) *WaitProcess {
+	return Default().RegisterSignalHandler(handler, sigs...)
+}
+
+// Reload reloads the processes of the WaitProcess implementing Reloadable.
+func Reload(ctx context.Context) error {
+	return Default().Reload(ctx)
+}
+
+// Dump writes a diagnostic dump of the WaitProcess to w.
+func Dump(w io.Writer) error {
+	return Default().Dump(w)
+}
+
 // Start starts the WaitProcess.
-func Start() {
-	Default().Start()
+func Start() error {
+	return Default().Start()
 }
 
 // Run starts the WaitProcess and waits for it to stop.
@@ -40,11 +78,51 @@ func Run() error {
 	return Default().Run()
 }
 
+// RunWithReason starts the WaitProcess, waits for it to stop and returns why it stopped.
+func RunWithReason() (StopReason, error) {
+	return Default().RunWithReason()
+}
+
+// GetStopReason returns why the WaitProcess stopped, it's named so as StopReason is the type.
+func GetStopReason() StopReason {
+	return Default().StopReason()
+}
+
 // Error returns the error of the WaitProcess.
 func Error() error {
 	return Default().Error()
 }
 
+// Ready returns a channel that is closed when all processes of the WaitProcess are ready.
+func Ready() <-chan struct{} {
+	return Default().Ready()
+}
+
+// WaitReady waits for all processes of the WaitProcess to be ready.
+func WaitReady(ctx context.Context) error {
+	return Default().WaitReady(ctx)
+}
+
+// Snapshot returns the status of every process of the WaitProcess.
+func Snapshot() []ProcessStatus {
+	return Default().Snapshot()
+}
+
+// Subscribe calls f with every lifecycle event of the WaitProcess.
+func Subscribe(f func(Event)) (unsubscribe func()) {
+	return Default().Subscribe(f)
+}
+
+// Events returns a channel receiving the lifecycle events of the WaitProcess.
+func Events() <-chan Event {
+	return Default().Events()
+}
+
+// MetricsHandler returns an http.Handler serving the metrics of the WaitProcess.
+func MetricsHandler() http.Handler {
+	return Default().MetricsHandler()
+}
+
 // Stop stops the WaitProcess.
 func Stop() {
 	Default().Stop()
@@ -65,7 +143,37 @@ func PreStartHook(name string, h hookFunc) *WaitProcess {
 	return Default().PreStartHook(name, h)
 }
 
+// PostStartHook adds a hook to be run once all processes are ready
+func PostStartHook(name string, h hookFunc) *WaitProcess {
+	return Default().PostStartHook(name, h)
+}
+
+// PreStopHook adds a hook to be run before any process is stopped
+func PreStopHook(name string, h hookFunc) *WaitProcess {
+	return Default().PreStopHook(name, h)
+}
+
 // AfterStopHook adds a hook to be run after the waitprocess stops
 func AfterStopHook(name string, h hookFunc) *WaitProcess {
 	return Default().AfterStopHook(name, h)
 }
+
+// PreStartHookWithCtx adds a hook to be run before the waitprocess starts, its error aborts the start
+func PreStartHookWithCtx(name string, h HookFunc, opts ...HookOption) *WaitProcess {
+	return Default().PreStartHookWithCtx(name, h, opts...)
+}
+
+// PostStartHookWithCtx adds a hook to be run once all processes are ready, its error stops the waitprocess
+func PostStartHookWithCtx(name string, h HookFunc, opts ...HookOption) *WaitProcess {
+	return Default().PostStartHookWithCtx(name, h, opts...)
+}
+
+// PreStopHookWithCtx adds a hook to be run before any process is stopped, its error is included in the error
+func PreStopHookWithCtx(name string, h HookFunc, opts ...HookOption) *WaitProcess {
+	return Default().PreStopHookWithCtx(name, h, opts...)
+}
+
+// AfterStopHookWithCtx adds a hook to be run after the waitprocess stops, its error is included in the error
+func AfterStopHookWithCtx(name string, h HookFunc, opts ...HookOption) *WaitProcess {
+	return Default().AfterStopHookWithCtx(name, h, opts...)
+}
@@ -0,0 +1,177 @@
+package waitprocess
+
+import (
+	"context"
+	"time"
+)
+
+// HealthAction is what happens when a process fails its health checks
+type HealthAction int
+
+const (
+	// HealthLogOnly only logs the failure
+	HealthLogOnly HealthAction = iota
+	// HealthRestart restarts the process in place, see Process
+	HealthRestart
+	// HealthStopGroup stops the waitprocess with a HealthCheckError
+	HealthStopGroup
+)
+
+func (a HealthAction) String() string {
+	switch a {
+	case HealthLogOnly:
+		return "log-only"
+	case HealthRestart:
+		return "restart"
+	case HealthStopGroup:
+		return "stop-group"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthCheck configures how a process implementing HealthChecker is probed
+type HealthCheck struct {
+	// Interval is the time between two checks
+	Interval time.Duration
+	// Timeout bounds a single check
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failures that triggers the action
+	FailureThreshold int
+	// Action is run when the failure threshold is reached
+	Action HealthAction
+}
+
+var defaultHealthCheck = HealthCheck{
+	Interval:         10 * time.Second,
+	Timeout:          5 * time.Second,
+	FailureThreshold: 3,
+	Action:           HealthLogOnly,
+}
+
+// HealthStatus is the result of the health checks of a process
+type HealthStatus struct {
+	Healthy             bool
+	LastCheck           time.Time
+	LastError           error
+	ConsecutiveFailures int
+}
+
+// Health returns the health status of the named process, false if the process
+// doesn't exist or doesn't implement HealthChecker
+func (wp *WaitProcess) Health(name string) (HealthStatus, bool) {
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+
+	ok, proc := wp.procs.load(name)
+	if !ok {
+		return HealthStatus{}, false
+	}
+
+	if _, ok := proc.proc.(HealthChecker); !ok {
+		return HealthStatus{}, false
+	}
+
+	return proc.healthStatus(), true
+}
+
+func (p *procstat) healthStatus() HealthStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.health
+}
+
+// recordHealth stores the result of a check and returns the number of consecutive failures
+func (p *procstat) recordHealth(err error) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.health.LastCheck = time.Now()
+	p.health.LastError = err
+	p.health.Healthy = err == nil
+	if err == nil {
+		p.health.ConsecutiveFailures = 0
+	} else {
+		p.health.ConsecutiveFailures++
+	}
+
+	return p.health.ConsecutiveFailures
+}
+
+func (p *procstat) resetHealth() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.health.ConsecutiveFailures = 0
+}
+
+// watchHealth probes the process until it exits and runs the configured action on failures
+func (wp *WaitProcess) watchHealth(proc *procstat, checker HealthChecker) {
+	check := proc.opt.healthCheck
+	if check.Interval <= 0 {
+		check.Interval = defaultHealthCheck.Interval
+	}
+
+	log := wp.log.WithField("proc", proc.name)
+
+	select {
+	case <-proc.started:
+	case <-proc.done:
+		return
+	}
+
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-proc.ctx.Done():
+			return
+		case <-proc.done:
+			return
+		case <-ticker.C:
+		}
+
+		err := probe(proc.ctx, checker, check.Timeout)
+		failures := proc.recordHealth(err)
+		if err == nil || failures < check.FailureThreshold {
+			continue
+		}
+
+		log := log.WithError(err).WithField("failures", failures)
+		switch check.Action {
+		case HealthRestart:
+			log.Error("Health check failed, restarting process")
+			proc.resetHealth()
+			proc.restart()
+		case HealthStopGroup:
+			log.Error("Health check failed, stopping WaitProcess")
+			wp.addError(proc.newError(PhaseRun, &HealthCheckError{Proc: proc.name, Err: err}))
+			wp.setStopReason(StopReason{Kind: StopReasonHealthCheck, Proc: proc.name, Err: err})
+			wp.cancel()
+			return
+		default:
+			log.Error("Health check failed")
+		}
+	}
+}
+
+// probe runs a single check bounded by timeout, even if the check ignores its context
+func probe(ctx context.Context, checker HealthChecker, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
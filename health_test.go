@@ -0,0 +1,138 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	newCheck := func(action HealthAction) ProcessOption {
+		return WithHealthCheck(HealthCheck{
+			Interval:         10 * time.Millisecond,
+			Timeout:          10 * time.Millisecond,
+			FailureThreshold: 2,
+			Action:           action,
+		})
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hp := withHealthprocess()
+		wp.RegisterProcess("health", hp, newCheck(HealthStopGroup))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		time.Sleep(100 * time.Millisecond)
+		status, ok := wp.Health("health")
+		assert.True(t, ok)
+		assert.True(t, status.Healthy)
+		assert.Nil(t, status.LastError)
+		assert.False(t, status.LastCheck.IsZero())
+	})
+
+	t.Run("log-only", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hp := withHealthprocess()
+		hp.setHealth(assert.AnError)
+		wp.RegisterProcess("health", hp, newCheck(HealthLogOnly))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		status, ok := wp.Health("health")
+		assert.True(t, ok)
+		assert.False(t, status.Healthy)
+		assert.ErrorIs(t, status.LastError, assert.AnError)
+		assert.GreaterOrEqual(t, status.ConsecutiveFailures, 2)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		wp := NewWaitProcess()
+		stat := &teststate{}
+		unhealthy := make(chan struct{})
+		var once sync.Once
+
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("health", &checkprocess{
+			Process: RunWithCtx(func(ctx context.Context) error {
+				stat.add()
+				<-ctx.Done()
+				return nil
+			}),
+			check: func(ctx context.Context) error {
+				if stat.getstate() == 1 {
+					return assert.AnError
+				}
+				once.Do(func() { close(unhealthy) })
+				return nil
+			},
+		}, newCheck(HealthRestart))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		select {
+		case <-unhealthy:
+		case <-time.After(time.Second):
+			assert.Fail(t, "process should be restarted")
+		}
+
+		assert.Equal(t, 2, stat.getstate(), "process should be run twice")
+		assert.Equal(t, 1, wp.procs.get("health").restartCount())
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+	})
+
+	t.Run("stop-group", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hp := withHealthprocess()
+		hp.setHealth(assert.AnError)
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("health", hp, newCheck(HealthStopGroup))
+
+		err := wp.Run()
+
+		var healthErr *HealthCheckError
+		assert.ErrorAs(t, err, &healthErr)
+		assert.Equal(t, "health", healthErr.Proc)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("check-timeout", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hang := make(chan struct{})
+		defer close(hang)
+
+		wp.RegisterProcess("health", &checkprocess{
+			Process: withTestprocess(),
+			check: func(ctx context.Context) error {
+				<-hang
+				return nil
+			},
+		}, newCheck(HealthLogOnly))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		time.Sleep(100 * time.Millisecond)
+		status, _ := wp.Health("health")
+		assert.ErrorIs(t, status.LastError, context.DeadlineExceeded)
+	})
+
+	t.Run("not-checker", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+
+		_, ok := wp.Health("test")
+		assert.False(t, ok)
+
+		_, ok = wp.Health("unknown")
+		assert.False(t, ok)
+	})
+}
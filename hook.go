@@ -0,0 +1,169 @@
+package waitprocess
+
+import (
+	"context"
+	"time"
+)
+
+type hookFunc func()
+
+func (f hookFunc) withCtx() HookFunc {
+	return func(context.Context) error {
+		f()
+		return nil
+	}
+}
+
+// HookFunc is a hook that receives a context and can fail, the context is done when the
+// hook timeout expires
+type HookFunc func(ctx context.Context) error
+
+type hook struct {
+	name    string
+	hook    HookFunc
+	timeout time.Duration
+}
+
+type hookOption struct {
+	timeout time.Duration
+}
+
+type HookOption func(*hookOption)
+
+// WithHookTimeout bounds the run of a hook, a hook that doesn't return in time fails with a
+// HookTimeoutError. zero means no bound
+func WithHookTimeout(timeout time.Duration) HookOption {
+	return func(opt *hookOption) {
+		opt.timeout = timeout
+	}
+}
+
+func newHook(name string, f HookFunc, opts ...HookOption) hook {
+	opt := hookOption{}
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return hook{name: name, hook: f, timeout: opt.timeout}
+}
+
+// PreStartHookWithCtx adds a hook to be run before the waitprocess starts, when it fails
+// Start and Run return its error and no process is launched
+func (wp *WaitProcess) PreStartHookWithCtx(name string, f HookFunc, opts ...HookOption) *WaitProcess {
+	return wp.addHook("PreStartHook", wp.preStartHooks, newHook(name, f, opts...))
+}
+
+// PostStartHookWithCtx adds a hook to be run once all processes are ready, e.g. to register
+// to service discovery. when it fails the waitprocess is stopped with its error
+func (wp *WaitProcess) PostStartHookWithCtx(name string, f HookFunc, opts ...HookOption) *WaitProcess {
+	return wp.addHook("PostStartHook", wp.postStartHooks, newHook(name, f, opts...))
+}
+
+// PreStopHookWithCtx adds a hook to be run when the waitprocess stops, before any process
+// is stopped, e.g. to deregister from a load balancer. its error is included in the error
+// of the waitprocess
+func (wp *WaitProcess) PreStopHookWithCtx(name string, f HookFunc, opts ...HookOption) *WaitProcess {
+	return wp.addHook("PreStopHook", wp.preStopHooks, newHook(name, f, opts...))
+}
+
+// AfterStopHookWithCtx adds a hook to be run after the waitprocess stops, its error is
+// included in the error of the waitprocess
+func (wp *WaitProcess) AfterStopHookWithCtx(name string, f HookFunc, opts ...HookOption) *WaitProcess {
+	return wp.addHook("AfterStopHook", wp.afterStopHooks, newHook(name, f, opts...))
+}
+
+func (wp *WaitProcess) addHook(kind string, hooks *orderMap[string, hook], h hook) *WaitProcess {
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+
+	if wp.getState() != stateReady {
+		wp.panicf("Cannot call %s() after WaitProcess has already started", kind)
+	}
+
+	if hooks.contains(h.name) {
+		wp.panicf("%s %s already exists", kind, h.name)
+	}
+
+	hooks.set(h.name, h)
+	return wp
+}
+
+// runPreStartHooks runs the pre-start hooks in order, it stops at the first failing hook
+func (wp *WaitProcess) runPreStartHooks() error {
+	var err error
+	wp.preStartHooks.rangeFunc(func(index int, name string, value hook) bool {
+		wp.log.WithField("hook", name).Debug("Running pre-start hook")
+		err = wp.runHook(wp.ctx, value)
+		return err == nil
+	})
+
+	return err
+}
+
+// runPostStartHooks runs the post-start hooks in order, the first failing hook stops the waitprocess
+func (wp *WaitProcess) runPostStartHooks() {
+	wp.postStartHooks.rangeFunc(func(index int, name string, value hook) bool {
+		wp.log.WithField("hook", name).Debug("Running post-start hook")
+		if err := wp.runHook(wp.ctx, value); err != nil {
+			if wp.ctx.Err() == nil {
+				wp.addError(err)
+				wp.setStopReason(StopReason{Kind: StopReasonHook, Err: err})
+				wp.cancel()
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// runPreStopHooks runs all pre-stop hooks in order and adds their errors
+func (wp *WaitProcess) runPreStopHooks(ctx context.Context) {
+	wp.preStopHooks.rangeFunc(func(index int, name string, value hook) bool {
+		wp.log.WithField("hook", name).Debug("Running pre-stop hook")
+		if err := wp.runHook(ctx, value); err != nil {
+			wp.addError(err)
+		}
+		return true
+	})
+}
+
+// runHook runs the hook bounded by its timeout, a hook that overruns it is abandoned.
+// the error is returned as a PhaseError
+func (wp *WaitProcess) runHook(ctx context.Context, h hook) error {
+	wp.events.emit(Event{Type: EventHookStarted, Hook: h.name})
+
+	err := h.run(ctx)
+	if err != nil {
+		err = &PhaseError{Name: h.name, Phase: PhaseHook, Err: err}
+		wp.log.WithField("hook", h.name).WithError(err).Error("Hook failed")
+	}
+
+	wp.events.emit(Event{Type: EventHookFinished, Hook: h.name, Err: err})
+	return err
+}
+
+func (h hook) run(ctx context.Context) error {
+	if h.timeout <= 0 {
+		return h.hook(ctx)
+	}
+
+	timeoutErr := &HookTimeoutError{Hook: h.name, Timeout: h.timeout}
+	ctx, cancel := context.WithTimeoutCause(ctx, h.timeout, timeoutErr)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if context.Cause(ctx) == timeoutErr {
+			return timeoutErr
+		}
+		// the context was cancelled by the caller, the hook is waited for like without timeout
+		return <-done
+	}
+}
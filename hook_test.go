@@ -0,0 +1,296 @@
+package waitprocess
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPreStartHookWithCtx(t *testing.T) {
+	t.Run("abort-start", func(t *testing.T) {
+		wp := NewWaitProcess()
+		started := make(chan struct{})
+		wp.RegisterProcess("test", RunWithCtx(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return nil
+		}))
+
+		recorder := &testrecorder{}
+		wp.PreStartHookWithCtx("config", func(ctx context.Context) error {
+			recorder.add("config")
+			return assert.AnError
+		})
+		wp.PreStartHook("next", func() {
+			recorder.add("next")
+		})
+
+		err := wp.Start()
+		assert.ErrorIs(t, err, assert.AnError)
+
+		var phaseErr *PhaseError
+		assert.True(t, errors.As(err, &phaseErr))
+		assert.Equal(t, "config", phaseErr.Name)
+		assert.Equal(t, PhaseHook, phaseErr.Phase)
+
+		assert.Equal(t, []string{"config"}, recorder.get())
+		select {
+		case <-started:
+			t.Fatal("process must not be started")
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+
+	t.Run("abort-run", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHookWithCtx("config", func(ctx context.Context) error {
+			return assert.AnError
+		})
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHookWithCtx("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithHookTimeout(10*time.Millisecond))
+
+		err := wp.Start()
+		var timeoutErr *HookTimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, "slow", timeoutErr.Hook)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("abandon-on-timeout", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		block := make(chan struct{})
+		defer close(block)
+		wp.PreStartHookWithCtx("stuck", func(ctx context.Context) error {
+			<-block
+			return nil
+		}, WithHookTimeout(10*time.Millisecond))
+
+		err := wp.Start()
+		assert.EqualError(t, err, "stuck (hook): hook stuck didn't finish within 10ms")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHookWithCtx("config", func(ctx context.Context) error {
+			assert.Nil(t, ctx.Err())
+			return nil
+		}, WithHookTimeout(time.Second))
+
+		assert.Nil(t, wp.Start())
+		assert.Nil(t, wp.Shutdown())
+	})
+}
+
+func TestAfterStopHookWithCtx(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+
+		recorder := &testrecorder{}
+		wp.AfterStopHookWithCtx("flush", func(ctx context.Context) error {
+			recorder.add("flush")
+			return assert.AnError
+		})
+		wp.AfterStopHook("close", func() {
+			recorder.add("close")
+		})
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.EqualError(t, err, "flush (hook): "+assert.AnError.Error())
+		assert.Equal(t, []string{"flush", "close"}, recorder.get())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.AfterStopHookWithCtx("flush", func(ctx context.Context) error {
+			// the hook still has time to run after the waitprocess context is cancelled
+			return ctx.Err()
+		})
+
+		wp.Start()
+		assert.Nil(t, wp.Shutdown())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.AfterStopHookWithCtx("flush", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithHookTimeout(10*time.Millisecond))
+
+		wp.Start()
+		err := wp.Shutdown()
+		var timeoutErr *HookTimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, 10*time.Millisecond, timeoutErr.Timeout)
+	})
+}
+
+func TestPostStartHook(t *testing.T) {
+	t.Run("after-ready", func(t *testing.T) {
+		wp := NewWaitProcess()
+		recorder := &testrecorder{}
+		ready := make(chan struct{})
+		wp.RegisterProcess("test", RunWithReady(func(ctx context.Context, markReady func()) error {
+			<-ready
+			recorder.add("ready")
+			markReady()
+			<-ctx.Done()
+			return nil
+		}))
+
+		registered := make(chan struct{})
+		wp.PostStartHook("register", func() {
+			recorder.add("register")
+			close(registered)
+		})
+
+		wp.Start()
+		close(ready)
+		<-registered
+		assert.Nil(t, wp.Shutdown())
+		assert.Equal(t, []string{"ready", "register"}, recorder.get())
+	})
+
+	t.Run("error-stops", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PostStartHookWithCtx("register", func(ctx context.Context) error {
+			return assert.AnError
+		})
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, StopReasonHook, wp.StopReason().Kind)
+	})
+
+	t.Run("stopped-before-ready", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", RunWithReady(func(ctx context.Context, markReady func()) error {
+			<-ctx.Done()
+			return nil
+		}))
+
+		called := false
+		wp.PostStartHook("register", func() {
+			called = true
+		})
+
+		wp.Start()
+		assert.Nil(t, wp.Shutdown())
+		assert.False(t, called)
+	})
+}
+
+func TestPreStopHook(t *testing.T) {
+	t.Run("before-stop", func(t *testing.T) {
+		wp := NewWaitProcess()
+		recorder := &testrecorder{}
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			time.Sleep(time.Hour)
+			return nil
+		}, func() {
+			recorder.add("stop")
+		}), WithStopTimeout(10*time.Millisecond))
+		wp.PreStopHook("deregister", func() {
+			recorder.add("deregister")
+		})
+		wp.AfterStopHook("close", func() {
+			recorder.add("close")
+		})
+
+		wp.Start()
+		wp.Shutdown()
+		assert.Equal(t, []string{"deregister", "stop", "close"}, recorder.get())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		stopped := false
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			return nil
+		}, func() {
+			stopped = true
+		}))
+		wp.PreStopHookWithCtx("deregister", func(ctx context.Context) error {
+			return assert.AnError
+		})
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.True(t, stopped)
+	})
+}
+
+func TestProcessHooks(t *testing.T) {
+	t.Run("before-run-and-after-exit", func(t *testing.T) {
+		wp := NewWaitProcess()
+		recorder := &testrecorder{}
+		stat := &teststate{}
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			stat.add()
+			recorder.add("run")
+			if stat.getstate() == 1 {
+				return assert.AnError
+			}
+			return nil
+		}, func() {}),
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(Backoff{Initial: time.Millisecond}),
+			WithBeforeRun(func(ctx context.Context) error {
+				recorder.add("before")
+				return nil
+			}),
+			WithAfterExit(func(ctx context.Context, err error) {
+				assert.Nil(t, ctx.Err())
+				if err != nil {
+					recorder.add("after " + err.Error())
+				} else {
+					recorder.add("after")
+				}
+			}),
+		)
+
+		assert.Nil(t, wp.Run())
+		assert.Equal(t, []string{
+			"before", "run", "after " + assert.AnError.Error(),
+			"before", "run", "after",
+		}, recorder.get())
+	})
+
+	t.Run("before-run-error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		called := false
+		wp.RegisterProcess("test", RunWithStopFunc(func() error {
+			called = true
+			return nil
+		}, func() {}), WithBeforeRun(func(ctx context.Context) error {
+			return assert.AnError
+		}))
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.EqualError(t, err, "test (run): before-run hook: "+assert.AnError.Error())
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,120 @@
+package waitprocess
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"log/slog"
+)
+
+// Logger is the logger of the waitprocess, WithField and WithError return a logger with
+// the field added
+type Logger interface {
+	WithField(key string, value any) Logger
+	WithError(err error) Logger
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// NewLogrusLogger returns a Logger writing to the logrus entry
+func NewLogrusLogger(entry *logrus.Entry) Logger {
+	return &logrusLogger{entry: entry}
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+func (l *logrusLogger) WithField(key string, value any) Logger {
+	return &logrusLogger{entry: l.entry.WithField(key, value)}
+}
+
+func (l *logrusLogger) WithError(err error) Logger {
+	return &logrusLogger{entry: l.entry.WithError(err)}
+}
+
+func (l *logrusLogger) Debug(msg string) {
+	l.entry.Debug(msg)
+}
+
+func (l *logrusLogger) Info(msg string) {
+	l.entry.Info(msg)
+}
+
+func (l *logrusLogger) Warn(msg string) {
+	l.entry.Warn(msg)
+}
+
+func (l *logrusLogger) Error(msg string) {
+	l.entry.Error(msg)
+}
+
+// NewSlogLogger returns a Logger writing to the slog handler, fields are added as attributes
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &slogLogger{logger: slog.New(handler)}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) WithField(key string, value any) Logger {
+	return &slogLogger{logger: l.logger.With(key, value)}
+}
+
+func (l *slogLogger) WithError(err error) Logger {
+	return &slogLogger{logger: l.logger.With("error", err)}
+}
+
+func (l *slogLogger) Debug(msg string) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg)
+}
+
+func (l *slogLogger) Info(msg string) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg)
+}
+
+func (l *slogLogger) Warn(msg string) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg)
+}
+
+func (l *slogLogger) Error(msg string) {
+	l.logger.Log(context.Background(), slog.LevelError, msg)
+}
+
+// NewNopLogger returns a Logger discarding everything
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (l nopLogger) WithField(string, any) Logger {
+	return l
+}
+
+func (l nopLogger) WithError(error) Logger {
+	return l
+}
+
+func (nopLogger) Debug(string) {}
+
+func (nopLogger) Info(string) {}
+
+func (nopLogger) Warn(string) {}
+
+func (nopLogger) Error(string) {}
+
+// Logger returns the logger of the waitprocess, e.g. to log the output of a process with it
+func (wp *WaitProcess) Logger() Logger {
+	return wp.log
+}
+
+// panicf logs the misuse of the waitprocess and panics with the message
+func (wp *WaitProcess) panicf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	wp.log.Error(msg)
+	panic(msg)
+}
@@ -0,0 +1,73 @@
+package waitprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	t.Run("fields", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := NewSlogLogger(slog.NewJSONHandler(buf, nil))
+		log.WithField("proc", "test").WithError(assert.AnError).Warn("Process exited")
+
+		record := map[string]any{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, "Process exited", record["msg"])
+		assert.Equal(t, "test", record["proc"])
+		assert.Equal(t, assert.AnError.Error(), record["error"])
+	})
+
+	t.Run("level", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := NewSlogLogger(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log.Debug("debug")
+		assert.Empty(t, buf.String())
+
+		log.Error("error")
+		assert.Contains(t, buf.String(), "level=ERROR msg=error")
+	})
+
+	t.Run("waitprocess", func(t *testing.T) {
+		buf := &syncbuffer{}
+		wp := NewWaitProcess(WithLogger(NewSlogLogger(slog.NewTextHandler(buf, nil))))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		wp.Shutdown()
+
+		assert.Contains(t, buf.String(), "msg=\"WaitProcess started\"")
+
+		wp.Logger().WithField("proc", "test").Info("output")
+		assert.Contains(t, buf.String(), "msg=output proc=test")
+	})
+}
+
+func TestNewLogrusLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	log := NewLogrusLogger(logrus.NewEntry(logger))
+	log.WithField("proc", "test").WithError(assert.AnError).Error("Process error")
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "error", record["level"])
+	assert.Equal(t, "Process error", record["msg"])
+	assert.Equal(t, "test", record["proc"])
+	assert.Equal(t, assert.AnError.Error(), record["error"])
+}
+
+func TestNewNopLogger(t *testing.T) {
+	wp := NewWaitProcess(WithLogger(NewNopLogger()))
+	wp.RegisterProcess("test", withTestprocess())
+	assert.Panics(t, func() {
+		wp.RegisterProcess("test", withTestprocess())
+	})
+}
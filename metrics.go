@@ -0,0 +1,224 @@
+package waitprocess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// durationBuckets are the upper bounds in seconds of the start and stop duration histograms
+var durationBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// histogram is a cumulative histogram of durations in seconds
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+func (h histogram) clone() histogram {
+	h.counts = append([]uint64{}, h.counts...)
+	return h
+}
+
+// procMetrics are the metrics of a process at the time they are collected
+type procMetrics struct {
+	status        ProcessStatus
+	failures      int
+	panics        int
+	startDuration histogram
+	stopDuration  histogram
+}
+
+func (p *procstat) metrics() procMetrics {
+	status := p.status()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return procMetrics{
+		status:        status,
+		failures:      p.failures,
+		panics:        p.panics,
+		startDuration: p.startDuration.clone(),
+		stopDuration:  p.stopDuration.clone(),
+	}
+}
+
+// MetricsHandler returns an http.Handler serving the metrics of the waitprocess in the
+// Prometheus text exposition format
+func (wp *WaitProcess) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := wp.WriteMetrics(w); err != nil {
+			wp.log.WithError(err).Error("Failed to write metrics")
+		}
+	})
+}
+
+// WriteMetrics writes the metrics of the waitprocess to w in the Prometheus text exposition format
+func (wp *WaitProcess) WriteMetrics(w io.Writer) error {
+	wp.lock.Lock()
+	procs := make([]procMetrics, 0, wp.procs.size())
+	wp.procs.rangeFunc(func(_ int, _ string, proc *procstat) bool {
+		procs = append(procs, proc.metrics())
+		return true
+	})
+	wp.lock.Unlock()
+
+	mw := &metricsWriter{w: bufio.NewWriter(w)}
+
+	groupState := wp.groupState()
+	mw.family("waitprocess_group_state", "gauge", "State of the waitprocess, 1 for the current state.")
+	for _, state := range []string{"pending", "running", "stopping", "stopped"} {
+		mw.sample("waitprocess_group_state", boolValue(state == groupState), "state", state)
+	}
+
+	mw.family("waitprocess_group_ready", "gauge", "Whether all processes are ready.")
+	mw.sample("waitprocess_group_ready", boolValue(isClosed(wp.readyChan)))
+
+	mw.family("waitprocess_process_up", "gauge", "Whether the process is running and ready.")
+	for _, proc := range procs {
+		mw.sample("waitprocess_process_up", boolValue(proc.status.State == ProcessRunning), "process", proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_uptime_seconds", "gauge", "Time since the current run of the process started.")
+	for _, proc := range procs {
+		mw.sample("waitprocess_process_uptime_seconds", proc.status.Uptime.Seconds(), "process", proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_restarts_total", "counter", "Number of restarts of the process.")
+	for _, proc := range procs {
+		mw.sample("waitprocess_process_restarts_total", float64(proc.status.Restarts), "process", proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_failures_total", "counter", "Number of runs of the process that returned an error.")
+	for _, proc := range procs {
+		mw.sample("waitprocess_process_failures_total", float64(proc.failures), "process", proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_panics_total", "counter", "Number of runs of the process that panicked.")
+	for _, proc := range procs {
+		mw.sample("waitprocess_process_panics_total", float64(proc.panics), "process", proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_healthy", "gauge", "Whether the last health check of the process passed.")
+	for _, proc := range procs {
+		if proc.status.Health != nil {
+			mw.sample("waitprocess_process_healthy", boolValue(proc.status.Health.Healthy), "process", proc.status.Name)
+		}
+	}
+
+	mw.family("waitprocess_process_start_duration_seconds", "histogram", "Time from the launch of the process until it is ready.")
+	for _, proc := range procs {
+		mw.histogram("waitprocess_process_start_duration_seconds", proc.startDuration, proc.status.Name)
+	}
+
+	mw.family("waitprocess_process_stop_duration_seconds", "histogram", "Time from the stop of the process until it exited.")
+	for _, proc := range procs {
+		mw.histogram("waitprocess_process_stop_duration_seconds", proc.stopDuration, proc.status.Name)
+	}
+
+	if mw.err != nil {
+		return mw.err
+	}
+	return mw.w.Flush()
+}
+
+// groupState returns the state of the waitprocess as reported by the metrics
+func (wp *WaitProcess) groupState() string {
+	switch {
+	case wp.getState() != stateStarted:
+		return "pending"
+	case wp.Stopped():
+		return "stopped"
+	case wp.StopReason().Kind != StopReasonNone:
+		return "stopping"
+	default:
+		return "running"
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// metricsWriter writes the text exposition format, it keeps the first write error
+type metricsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (mw *metricsWriter) printf(format string, args ...any) {
+	if mw.err == nil {
+		_, mw.err = fmt.Fprintf(mw.w, format, args...)
+	}
+}
+
+func (mw *metricsWriter) family(name, typ, help string) {
+	mw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a sample, labels are pairs of name and value
+func (mw *metricsWriter) sample(name string, value float64, labels ...string) {
+	mw.printf("%s%s %s\n", name, formatLabels(labels), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (mw *metricsWriter) histogram(name string, h histogram, proc string) {
+	for i, bound := range durationBuckets {
+		var count uint64
+		if i < len(h.counts) {
+			count = h.counts[i]
+		}
+		mw.sample(name+"_bucket", float64(count), "process", proc, "le", strconv.FormatFloat(bound, 'g', -1, 64))
+	}
+
+	mw.sample(name+"_bucket", float64(h.count), "process", proc, "le", "+Inf")
+	mw.sample(name+"_sum", h.sum, "process", proc)
+	mw.sample(name+"_count", float64(h.count), "process", proc)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,100 @@
+package waitprocess
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	metrics := func(wp *WaitProcess) string {
+		buf := &bytes.Buffer{}
+		assert.Nil(t, wp.WriteMetrics(buf))
+		return buf.String()
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+
+		out := metrics(wp)
+		assert.Contains(t, out, "# TYPE waitprocess_group_state gauge\n")
+		assert.Contains(t, out, `waitprocess_group_state{state="pending"} 1`)
+		assert.Contains(t, out, `waitprocess_group_state{state="running"} 0`)
+		assert.Contains(t, out, `waitprocess_process_up{process="test"} 0`)
+		assert.Contains(t, out, `waitprocess_process_start_duration_seconds_count{process="test"} 0`)
+	})
+
+	t.Run("running", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicRestart))
+		stat := &teststate{}
+		health := withHealthprocess()
+		wp.RegisterProcess("health", health, WithHealthCheck(HealthCheck{Interval: 5 * time.Millisecond, FailureThreshold: 1}))
+		wp.RegisterProcess("flaky", RunWithCtx(func(ctx context.Context) error {
+			stat.add()
+			if stat.getstate() == 1 {
+				return assert.AnError
+			}
+			if stat.getstate() == 2 {
+				panic("boom")
+			}
+			<-ctx.Done()
+			return nil
+		}), WithRestartPolicy(RestartAlways), WithBackoff(Backoff{Initial: time.Millisecond}))
+		wp.Start()
+
+		assert.Nil(t, wp.WaitReady(context.Background()))
+		assert.Eventually(t, func() bool {
+			return stat.getstate() == 3
+		}, time.Second, time.Millisecond)
+		health.setHealth(assert.AnError)
+		time.Sleep(20 * time.Millisecond)
+
+		out := metrics(wp)
+		assert.Contains(t, out, `waitprocess_group_state{state="running"} 1`)
+		assert.Contains(t, out, "waitprocess_group_ready 1\n")
+		assert.Contains(t, out, `waitprocess_process_up{process="flaky"} 1`)
+		assert.Contains(t, out, `waitprocess_process_restarts_total{process="flaky"} 2`)
+		assert.Contains(t, out, `waitprocess_process_failures_total{process="flaky"} 1`)
+		assert.Contains(t, out, `waitprocess_process_panics_total{process="flaky"} 1`)
+		assert.Contains(t, out, `waitprocess_process_healthy{process="health"} 0`)
+		assert.NotContains(t, out, `waitprocess_process_healthy{process="flaky"}`)
+		assert.Contains(t, out, `waitprocess_process_start_duration_seconds_bucket{process="flaky",le="+Inf"} 1`)
+		assert.Contains(t, out, `waitprocess_process_start_duration_seconds_count{process="flaky"} 1`)
+
+		wp.Shutdown()
+		out = metrics(wp)
+		assert.Contains(t, out, `waitprocess_group_state{state="stopped"} 1`)
+		assert.Contains(t, out, `waitprocess_process_stop_duration_seconds_count{process="flaky"} 1`)
+		assert.Contains(t, out, `waitprocess_process_stop_duration_seconds_bucket{process="flaky",le="60"} 1`)
+	})
+
+	t.Run("escape", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("a\"b\\c", withTestprocess())
+
+		assert.Contains(t, metrics(wp), `waitprocess_process_up{process="a\"b\\c"} 0`)
+	})
+}
+
+func TestMetricsHandler(t *testing.T) {
+	wp := NewWaitProcess()
+	wp.RegisterProcess("test", withTestprocess())
+	wp.Start()
+	defer wp.Shutdown()
+
+	assert.Eventually(t, func() bool {
+		return wp.Snapshot()[0].State == ProcessRunning
+	}, time.Second, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	wp.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Contains(t, rec.Body.String(), `waitprocess_process_up{process="test"} 1`)
+}
@@ -3,21 +3,32 @@ package waitprocess
 import (
 	"context"
 	"github.com/sirupsen/logrus"
+	"io"
+	"os"
 	"time"
 )
 
 type waitProcessOption struct {
-	ctx   context.Context
-	log   *logrus.Entry
-	timer *time.Timer
+	ctx             context.Context
+	log             Logger
+	timer           *time.Timer
+	order           ShutdownOrder
+	shutdownTimeout time.Duration
+	startupTimeout  time.Duration
+	panicPolicy     PanicPolicy
+	onPanic         func(*ProcessError)
+	stopOnReload    bool
+	dumpWriter      io.Writer
+	escalation      *Escalation
 }
 
 type WaitProcessOption func(*waitProcessOption)
 
 func newWaitProcessOption(opts ...WaitProcessOption) waitProcessOption {
 	opt := waitProcessOption{
-		ctx: context.Background(),
-		log: logrus.WithField("pkg", "waitprocess"),
+		ctx:        context.Background(),
+		log:        NewLogrusLogger(logrus.WithField("pkg", "waitprocess")),
+		dumpWriter: os.Stderr,
 	}
 
 	for _, o := range opts {
@@ -27,8 +38,15 @@ func newWaitProcessOption(opts ...WaitProcessOption) waitProcessOption {
 	return opt
 }
 
-// WithLog sets the logger for the waitprocess
+// WithLog sets the logrus logger for the waitprocess
 func WithLog(log *logrus.Entry) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.log = NewLogrusLogger(log)
+	}
+}
+
+// WithLogger sets the logger for the waitprocess, e.g. NewSlogLogger or NewNopLogger
+func WithLogger(log Logger) WaitProcessOption {
 	return func(opt *waitProcessOption) {
 		opt.log = log
 	}
@@ -47,3 +65,64 @@ func WithTimer(timer time.Duration) WaitProcessOption {
 		opt.timer = time.NewTimer(timer)
 	}
 }
+
+// WithShutdownOrder sets the order in which processes are stopped
+func WithShutdownOrder(order ShutdownOrder) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.order = order
+	}
+}
+
+// WithShutdownTimeout bounds the whole stop phase, process stops plus after-stop hooks,
+// when exceeded Wait returns a ShutdownTimeoutError. zero means no bound
+func WithShutdownTimeout(timeout time.Duration) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.shutdownTimeout = timeout
+	}
+}
+
+// WithStartupTimeout fails the waitprocess if not all processes are ready within the timeout.
+// zero means no bound
+func WithStartupTimeout(timeout time.Duration) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.startupTimeout = timeout
+	}
+}
+
+// WithPanicPolicy sets what happens when a process panics
+func WithPanicPolicy(policy PanicPolicy) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.panicPolicy = policy
+	}
+}
+
+// WithPanicHandler sets a function called with every panic of a process, e.g. to report it
+// to a crash collector. it is called before the panic policy is applied
+func WithPanicHandler(f func(*ProcessError)) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.onPanic = f
+	}
+}
+
+// WithStopOnReloadFailure sets whether a failed reload of a process stops the waitprocess,
+// by default the failure is only reported
+func WithStopOnReloadFailure(stop bool) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.stopOnReload = stop
+	}
+}
+
+// WithDumpWriter sets where the diagnostic dump of SignalDump is written, os.Stderr by default
+func WithDumpWriter(w io.Writer) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.dumpWriter = w
+	}
+}
+
+// WithEscalation forces the exit of the program when the graceful shutdown hangs, see Escalation.
+// without it stop signals received while stopping are ignored
+func WithEscalation(escalation Escalation) WaitProcessOption {
+	return func(opt *waitProcessOption) {
+		opt.escalation = &escalation
+	}
+}
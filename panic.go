@@ -0,0 +1,27 @@
+package waitprocess
+
+// PanicPolicy decides what happens when a process panics
+type PanicPolicy int
+
+const (
+	// PanicRePanic stops the waitprocess and re-panics the value in Wait
+	PanicRePanic PanicPolicy = iota
+	// PanicAsError converts the panic into a ProcessError holding the value and the stack trace,
+	// the exit policy of the process then applies
+	PanicAsError
+	// PanicRestart restarts the process with its backoff, regardless of its restart policy
+	PanicRestart
+)
+
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicRePanic:
+		return "re-panic"
+	case PanicAsError:
+		return "as-error"
+	case PanicRestart:
+		return "restart"
+	default:
+		return "unknown"
+	}
+}
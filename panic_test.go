@@ -0,0 +1,120 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithPanicPolicy(t *testing.T) {
+	t.Run("re-panic", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicRePanic))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			panic("boom")
+		}))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			wp.Run()
+		})
+	})
+
+	t.Run("as-error", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicAsError))
+		tp := withTestprocess()
+		wp.RegisterProcess("test", tp)
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			panic("boom")
+		}))
+
+		var err error
+		assert.NotPanics(t, func() {
+			err = wp.Run()
+		})
+
+		var procErr *ProcessError
+		assert.ErrorAs(t, err, &procErr)
+		assert.Equal(t, "panic", procErr.Name)
+		assert.Equal(t, "boom", procErr.Panic)
+		assert.Contains(t, string(procErr.Stack), "TestWithPanicPolicy")
+		assert.Equal(t, 1, tp.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("as-error-ignore-exit", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicAsError))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			panic("boom")
+		}), WithExitPolicy(IgnoreExit))
+
+		wp.Start()
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicRestart))
+		stat := &teststate{}
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			stat.add()
+			if stat.getstate() < 3 {
+				panic("boom")
+			}
+			<-ctx.Done()
+			return nil
+		}), WithBackoff(Backoff{Initial: time.Millisecond}))
+
+		wp.Start()
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+		assert.Equal(t, 3, stat.getstate(), "process should be run 3 times")
+		assert.Equal(t, 2, wp.procs.get("panic").restartCount())
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+	})
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var lock sync.Mutex
+	reported := make([]*ProcessError, 0)
+
+	wp := NewWaitProcess(
+		WithPanicPolicy(PanicRestart),
+		WithPanicHandler(func(err *ProcessError) {
+			lock.Lock()
+			defer lock.Unlock()
+			reported = append(reported, err)
+		}),
+	)
+
+	stat := &teststate{}
+	wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+		stat.add()
+		if stat.getstate() < 3 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	}), WithBackoff(Backoff{Initial: time.Millisecond}))
+
+	wp.Start()
+	time.Sleep(100 * time.Millisecond)
+	err := wp.Shutdown()
+	assert.Nil(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Len(t, reported, 2)
+	for i, procErr := range reported {
+		assert.Equal(t, "panic", procErr.Name)
+		assert.Equal(t, "boom", procErr.Panic)
+		assert.Equal(t, i, procErr.Restarts)
+		assert.NotEmpty(t, procErr.Stack)
+	}
+}
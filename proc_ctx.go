@@ -23,3 +23,22 @@ func (p *ctxProcess) Run() error {
 func (p *ctxProcess) Stop() {
 	// do nothing
 }
+
+type processNameKey struct{}
+
+// ProcessName returns the name the process is registered with, from the context given to
+// SetContext, false if the context is not the one of a process
+func ProcessName(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(processNameKey{}).(string)
+	return name, ok
+}
+
+type restartRequestedKey struct{}
+
+// RestartRequested returns true if the process of the context given to SetContext is
+// restarted in place, e.g. by WaitProcess.RestartProcess, so Stop can tell a restart from
+// the final stop of the process
+func RestartRequested(ctx context.Context) bool {
+	restarting, ok := ctx.Value(restartRequestedKey{}).(func() bool)
+	return ok && restarting()
+}
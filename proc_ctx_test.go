@@ -3,6 +3,7 @@ package waitprocess
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
 	"time"
 )
@@ -89,3 +90,74 @@ func TestRunWithCtx(t *testing.T) {
 		assert.Equal(t, 1, stat.getstate(), "state should be 1")
 	})
 }
+
+func TestProcessName(t *testing.T) {
+	wp := NewWaitProcess()
+	names := make(chan string, 1)
+	wp.RegisterProcess("named", RunWithCtx(func(ctx context.Context) error {
+		name, _ := ProcessName(ctx)
+		names <- name
+		return nil
+	}))
+
+	assert.Nil(t, wp.Run())
+	assert.Equal(t, "named", <-names)
+
+	_, ok := ProcessName(context.Background())
+	assert.False(t, ok)
+}
+
+// restartprocess records whether it's restarted on every Stop. like processes that run again
+// after Stop, SetContext resets a previous Stop
+type restartprocess struct {
+	lock       sync.Mutex
+	ctx        context.Context
+	stop       chan struct{}
+	restarting []bool
+}
+
+func (p *restartprocess) SetContext(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.ctx = ctx
+	p.stop = make(chan struct{})
+}
+
+func (p *restartprocess) Run() error {
+	p.lock.Lock()
+	stop := p.stop
+	p.lock.Unlock()
+	<-stop
+	return nil
+}
+
+func (p *restartprocess) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.restarting = append(p.restarting, RestartRequested(p.ctx))
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+}
+
+func TestRestartRequested(t *testing.T) {
+	wp := NewWaitProcess(WithLogger(NewNopLogger()))
+	proc := &restartprocess{}
+	wp.RegisterProcess("proc", proc)
+	assert.Nil(t, wp.Start())
+	assert.Nil(t, wp.WaitReady(context.Background()))
+
+	assert.Nil(t, wp.RestartProcess("proc"))
+	assert.Eventually(t, func() bool {
+		status := wp.Snapshot()[0]
+		return status.Restarts == 1 && status.State == ProcessRunning
+	}, time.Second*5, time.Millisecond*10)
+	assert.Nil(t, wp.Shutdown(time.Second*5))
+
+	proc.lock.Lock()
+	defer proc.lock.Unlock()
+	assert.Equal(t, []bool{true, false}, proc.restarting)
+	assert.False(t, RestartRequested(context.Background()))
+}
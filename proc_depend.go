@@ -0,0 +1,125 @@
+package waitprocess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveDependencies links every process to its dependencies and returns the processes
+// in start order, processes without dependency relations keep their registration order
+func resolveDependencies(procs *orderMap[string, *procstat]) ([]*procstat, error) {
+	registered := make([]*procstat, 0, procs.size())
+	procs.rangeFunc(func(_ int, _ string, proc *procstat) bool {
+		proc.deps = proc.deps[:0]
+		proc.dependents = proc.dependents[:0]
+		registered = append(registered, proc)
+		return true
+	})
+
+	for _, proc := range registered {
+		if err := linkDependencies(procs, proc); err != nil {
+			return nil, err
+		}
+	}
+
+	order, cycle := sortProcs(registered, func(proc *procstat) []*procstat {
+		return proc.deps
+	})
+
+	if len(cycle) > 0 {
+		names := make([]string, 0, len(cycle))
+		for _, proc := range cycle {
+			names = append(names, proc.name)
+		}
+		return nil, fmt.Errorf("dependency cycle between processes %s", strings.Join(names, ", "))
+	}
+
+	return order, nil
+}
+
+// linkDependencies links the process to its dependencies, they must be registered in procs
+func linkDependencies(procs *orderMap[string, *procstat], proc *procstat) error {
+	for _, dep := range proc.opt.dependsOn {
+		if !procs.contains(dep) {
+			return fmt.Errorf("process %s depends on unknown process %s", proc.name, dep)
+		}
+	}
+
+	for _, dep := range proc.opt.dependsOn {
+		depProc := procs.get(dep)
+		proc.deps = append(proc.deps, depProc)
+		depProc.dependents = append(depProc.dependents, proc)
+	}
+
+	return nil
+}
+
+// unlinkDependencies removes a process from the dependents of its dependencies
+func unlinkDependencies(proc *procstat) {
+	for _, dep := range proc.deps {
+		for i, dependent := range dep.dependents {
+			if dependent == proc {
+				dep.dependents = append(dep.dependents[:i], dep.dependents[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// dependentsOf returns the names of the processes that depend on the named process
+func dependentsOf(procs *orderMap[string, *procstat], name string) []string {
+	dependents := make([]string, 0)
+	procs.rangeFunc(func(_ int, key string, proc *procstat) bool {
+		for _, dep := range proc.opt.dependsOn {
+			if dep == name {
+				dependents = append(dependents, key)
+				break
+			}
+		}
+		return true
+	})
+
+	return dependents
+}
+
+// sortProcs sorts procs so that every process comes after the processes returned by before,
+// keeping the given order where possible. processes that can't be sorted are returned as cycle
+func sortProcs(procs []*procstat, before func(*procstat) []*procstat) (order []*procstat, cycle []*procstat) {
+	order = make([]*procstat, 0, len(procs))
+	sorted := make(map[*procstat]bool, len(procs))
+
+	for len(order) < len(procs) {
+		progress := false
+		for _, proc := range procs {
+			if sorted[proc] || !allSorted(sorted, before(proc)) {
+				continue
+			}
+
+			sorted[proc] = true
+			order = append(order, proc)
+			progress = true
+			break
+		}
+
+		if !progress {
+			for _, proc := range procs {
+				if !sorted[proc] {
+					cycle = append(cycle, proc)
+				}
+			}
+			return order, cycle
+		}
+	}
+
+	return order, nil
+}
+
+func allSorted(sorted map[*procstat]bool, procs []*procstat) bool {
+	for _, proc := range procs {
+		if !sorted[proc] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,127 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type testrecorder struct {
+	lock    sync.Mutex
+	records []string
+}
+
+func (r *testrecorder) add(record string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *testrecorder) get() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string{}, r.records...)
+}
+
+func Test_resolveDependencies(t *testing.T) {
+	names := func(order []*procstat) []string {
+		result := make([]string, 0, len(order))
+		for _, proc := range order {
+			result = append(result, proc.name)
+		}
+		return result
+	}
+
+	register := func(procs *orderMap[string, *procstat], name string, deps ...string) {
+		procs.set(name, newProcstat(name, withTestprocess(), newProcessOption(DependsOn(deps...))))
+	}
+
+	t.Run("registration-order", func(t *testing.T) {
+		procs := newOrderMap[string, *procstat]()
+		register(procs, "a")
+		register(procs, "b")
+		register(procs, "c")
+
+		order, err := resolveDependencies(procs)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, names(order))
+	})
+
+	t.Run("dependency-order", func(t *testing.T) {
+		procs := newOrderMap[string, *procstat]()
+		register(procs, "http", "db", "cache")
+		register(procs, "cache", "db")
+		register(procs, "worker")
+		register(procs, "db")
+
+		order, err := resolveDependencies(procs)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"worker", "db", "cache", "http"}, names(order))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		procs := newOrderMap[string, *procstat]()
+		register(procs, "http", "db")
+
+		_, err := resolveDependencies(procs)
+		assert.EqualError(t, err, "process http depends on unknown process db")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		procs := newOrderMap[string, *procstat]()
+		register(procs, "a", "c")
+		register(procs, "b", "a")
+		register(procs, "c", "b")
+		register(procs, "d")
+
+		_, err := resolveDependencies(procs)
+		assert.EqualError(t, err, "dependency cycle between processes a, b, c")
+	})
+}
+
+func TestDependsOn(t *testing.T) {
+	t.Run("start-and-stop-order", func(t *testing.T) {
+		wp := NewWaitProcess()
+		recorder := &testrecorder{}
+
+		newProc := func(name string) Process {
+			return RunWithCtx(func(ctx context.Context) error {
+				recorder.add("start " + name)
+				<-ctx.Done()
+				recorder.add("stop " + name)
+				return nil
+			})
+		}
+
+		wp.RegisterProcess("http", newProc("http"), DependsOn("db"))
+		wp.RegisterProcess("db", newProc("db"))
+
+		wp.Start()
+		<-wp.procs.get("http").started
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+
+		records := recorder.get()
+		assert.ElementsMatch(t, []string{"start db", "start http"}, records[:2])
+		assert.Equal(t, []string{"stop http", "stop db"}, records[2:])
+	})
+
+	t.Run("unknown-dependency", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp := withTestprocess()
+		wp.RegisterProcess("http", tp, DependsOn("db"))
+
+		assert.EqualError(t, wp.Start(), "process http depends on unknown process db")
+		assert.EqualError(t, wp.Run(), "process http depends on unknown process db")
+		assert.Equal(t, 0, tp.getRunCount())
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("a", withTestprocess(), DependsOn("b"))
+		wp.RegisterProcess("b", withTestprocess(), DependsOn("a"))
+
+		assert.EqualError(t, wp.Start(), "dependency cycle between processes a, b")
+	})
+}
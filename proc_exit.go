@@ -0,0 +1,39 @@
+package waitprocess
+
+// ExitPolicy decides whether the exit of a process stops the waitprocess, it applies once
+// the restart policy gave up on the process. a panic is an error exit with PanicAsError and
+// PanicRestart, it always stops the waitprocess with PanicRePanic
+type ExitPolicy int
+
+const (
+	// StopGroupOnExit stops the waitprocess whenever the process exits
+	StopGroupOnExit ExitPolicy = iota
+	// StopGroupOnError stops the waitprocess only when the process returns an error
+	StopGroupOnError
+	// IgnoreExit never stops the waitprocess, errors are only logged
+	IgnoreExit
+)
+
+func (p ExitPolicy) String() string {
+	switch p {
+	case StopGroupOnExit:
+		return "stop-group-on-exit"
+	case StopGroupOnError:
+		return "stop-group-on-error"
+	case IgnoreExit:
+		return "ignore-exit"
+	default:
+		return "unknown"
+	}
+}
+
+func (p ExitPolicy) stopsGroup(err error) bool {
+	switch p {
+	case StopGroupOnError:
+		return err != nil
+	case IgnoreExit:
+		return false
+	default:
+		return true
+	}
+}
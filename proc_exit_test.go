@@ -0,0 +1,84 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWithExitPolicy(t *testing.T) {
+	t.Run("stop-group-on-exit", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp := withTestprocess()
+		wp.RegisterProcess("test", tp)
+		wp.RegisterProcess("job", withSleepprocess(10*time.Millisecond), WithExitPolicy(StopGroupOnExit))
+
+		err := wp.Run()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, tp.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("stop-group-on-error-success", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", withSleepprocess(10*time.Millisecond), WithExitPolicy(StopGroupOnError))
+
+		wp.Start()
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+	})
+
+	t.Run("stop-group-on-error-failure", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", withErrprocess(assert.AnError), WithExitPolicy(StopGroupOnError))
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("ignore-exit", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", withErrprocess(assert.AnError), WithExitPolicy(IgnoreExit))
+
+		wp.Start()
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+	})
+
+	t.Run("ignore-exit-ready", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", RunWithReady(func(ctx context.Context, ready func()) error {
+			return nil
+		}), WithExitPolicy(IgnoreExit))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err := wp.WaitReady(ctx)
+		assert.Nil(t, err)
+	})
+
+	t.Run("ignore-exit-panic", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", RunWithCtx(func(ctx context.Context) error {
+			panic("panic")
+		}), WithExitPolicy(IgnoreExit))
+
+		assert.Panics(t, func() {
+			wp.Run()
+		})
+	})
+}
@@ -2,8 +2,29 @@ package waitprocess
 
 import "context"
 
+// Process is run by the waitprocess, SetContext is called before every run and Stop makes the
+// current run return. a process restarted in place, by its restart policy, the HealthRestart
+// action or RestartProcess, is run again on the same value after Stop, it must stay runnable then
 type Process interface {
 	Run() error
 	Stop()
 	SetContext(ctx context.Context)
 }
+
+// ReadyNotifier is implemented by processes that signal when they are ready, NotifyReady is
+// called before Run with the function to call once ready. other processes are ready once started
+type ReadyNotifier interface {
+	NotifyReady(ready func())
+}
+
+// HealthChecker is implemented by processes that can be probed for their health,
+// Check is called periodically while the process runs
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// Reloadable is implemented by processes that can reload their configuration without
+// a restart, Reload is called on the reload signal or by WaitProcess.Reload
+type Reloadable interface {
+	Reload(ctx context.Context) error
+}
@@ -0,0 +1,93 @@
+package waitprocess
+
+import (
+	"context"
+	"time"
+)
+
+type processOption struct {
+	restartPolicy RestartPolicy
+	backoff       Backoff
+	dependsOn     []string
+	stopTimeout   time.Duration
+	healthCheck   HealthCheck
+	exitPolicy    ExitPolicy
+	beforeRun     HookFunc
+	afterExit     func(ctx context.Context, err error)
+}
+
+type ProcessOption func(*processOption)
+
+func newProcessOption(opts ...ProcessOption) processOption {
+	opt := processOption{
+		restartPolicy: RestartNever,
+		backoff:       defaultBackoff,
+		healthCheck:   defaultHealthCheck,
+		exitPolicy:    StopGroupOnExit,
+	}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// WithRestartPolicy sets when the process is restarted after it exits
+func WithRestartPolicy(policy RestartPolicy) ProcessOption {
+	return func(opt *processOption) {
+		opt.restartPolicy = policy
+	}
+}
+
+// WithBackoff sets the backoff used between restarts of the process
+func WithBackoff(backoff Backoff) ProcessOption {
+	return func(opt *processOption) {
+		opt.backoff = backoff
+	}
+}
+
+// DependsOn sets the processes that must be started before the process, and stopped after it.
+// Start fails if one of them is not registered or the dependencies form a cycle
+func DependsOn(names ...string) ProcessOption {
+	return func(opt *processOption) {
+		opt.dependsOn = append(opt.dependsOn, names...)
+	}
+}
+
+// WithStopTimeout sets how long the process may take to exit after it is stopped,
+// after that it is abandoned and the shutdown proceeds. zero means wait forever
+func WithStopTimeout(timeout time.Duration) ProcessOption {
+	return func(opt *processOption) {
+		opt.stopTimeout = timeout
+	}
+}
+
+// WithHealthCheck sets how a process implementing HealthChecker is probed
+func WithHealthCheck(check HealthCheck) ProcessOption {
+	return func(opt *processOption) {
+		opt.healthCheck = check
+	}
+}
+
+// WithExitPolicy sets whether the exit of the process stops the waitprocess
+func WithExitPolicy(policy ExitPolicy) ProcessOption {
+	return func(opt *processOption) {
+		opt.exitPolicy = policy
+	}
+}
+
+// WithBeforeRun sets a hook run before every run of the process, restarts included. when it
+// fails the process isn't run and the run fails with its error
+func WithBeforeRun(f HookFunc) ProcessOption {
+	return func(opt *processOption) {
+		opt.beforeRun = f
+	}
+}
+
+// WithAfterExit sets a hook run after every run of the process with the error of the run
+func WithAfterExit(f func(ctx context.Context, err error)) ProcessOption {
+	return func(opt *processOption) {
+		opt.afterExit = f
+	}
+}
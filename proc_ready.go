@@ -0,0 +1,30 @@
+package waitprocess
+
+import "context"
+
+type readyProcess struct {
+	ctx   context.Context
+	ready func()
+	run   func(context.Context, func()) error
+}
+
+// RunWithReady creates a process that runs with a context and calls ready once it is ready
+func RunWithReady(run func(ctx context.Context, ready func()) error) Process {
+	return &readyProcess{run: run}
+}
+
+func (p *readyProcess) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+func (p *readyProcess) NotifyReady(ready func()) {
+	p.ready = ready
+}
+
+func (p *readyProcess) Run() error {
+	return p.run(p.ctx, p.ready)
+}
+
+func (p *readyProcess) Stop() {
+	// do nothing
+}
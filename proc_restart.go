@@ -0,0 +1,93 @@
+package waitprocess
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RestartPolicy decides whether a process is run again after it exits
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the process, its exit stops the waitprocess
+	RestartNever RestartPolicy = iota
+	// RestartAlways restarts the process whenever it exits
+	RestartAlways
+	// RestartOnFailure restarts the process only when it returns an error
+	RestartOnFailure
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartAlways:
+		return "always"
+	case RestartOnFailure:
+		return "on-failure"
+	default:
+		return "unknown"
+	}
+}
+
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Backoff is the exponential backoff used between restarts of a process
+type Backoff struct {
+	// Initial is the delay before the first restart
+	Initial time.Duration
+	// Max is the upper bound of the delay, zero means no bound
+	Max time.Duration
+	// Multiplier grows the delay after each restart, values below 1 are treated as 1
+	Multiplier float64
+	// Jitter randomizes the delay by up to this fraction of it, e.g. 0.2 means ±20%
+	Jitter float64
+}
+
+var defaultBackoff = Backoff{
+	Initial:    time.Second,
+	Max:        time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// delay returns the delay before the nth restart, n starts from 0
+func (b Backoff) delay(n int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(n))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+
+	if d < 0 {
+		return 0
+	}
+
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(d)
+}
@@ -0,0 +1,137 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		b := Backoff{Initial: time.Millisecond, Max: time.Second, Multiplier: 2}
+		assert.Equal(t, time.Millisecond, b.delay(0))
+		assert.Equal(t, 2*time.Millisecond, b.delay(1))
+		assert.Equal(t, 8*time.Millisecond, b.delay(3))
+	})
+
+	t.Run("max", func(t *testing.T) {
+		b := Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			assert.LessOrEqual(t, b.delay(10), 5*time.Second)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		b := Backoff{Initial: time.Second, Multiplier: 1, Jitter: 0.2}
+		for i := 0; i < 100; i++ {
+			d := b.delay(0)
+			assert.GreaterOrEqual(t, d, 800*time.Millisecond)
+			assert.LessOrEqual(t, d, 1200*time.Millisecond)
+		}
+	})
+
+	t.Run("multiplier-below-one", func(t *testing.T) {
+		b := Backoff{Initial: time.Second}
+		assert.Equal(t, time.Second, b.delay(5))
+	})
+}
+
+func TestRestartPolicy(t *testing.T) {
+	backoff := WithBackoff(Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2})
+
+	t.Run("always", func(t *testing.T) {
+		wp := NewWaitProcess()
+		stat := &teststate{}
+		wp.RegisterProcess("loop", withTestprocess())
+		wp.RegisterProcess("restart", RunWithCtx(func(ctx context.Context) error {
+			stat.add()
+			return nil
+		}), WithRestartPolicy(RestartAlways), backoff)
+
+		wp.Start()
+		time.Sleep(200 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Greater(t, stat.getstate(), 1, "process should be restarted")
+
+		proc := wp.procs.get("restart")
+		assert.Equal(t, stat.getstate()-1, proc.restartCount())
+		assert.False(t, proc.lastRestartTime().IsZero())
+	})
+
+	t.Run("on-failure", func(t *testing.T) {
+		wp := NewWaitProcess()
+		stat := &teststate{}
+		wp.RegisterProcess("loop", withTestprocess())
+		wp.RegisterProcess("restart", RunWithCtx(func(ctx context.Context) error {
+			stat.add()
+			if stat.getstate() < 3 {
+				return assert.AnError
+			}
+			return nil
+		}), WithRestartPolicy(RestartOnFailure), backoff)
+
+		err := wp.Run()
+		assert.Nil(t, err)
+		assert.Equal(t, 3, stat.getstate(), "process should be run 3 times")
+		assert.Equal(t, 2, wp.procs.get("restart").restartCount())
+	})
+
+	t.Run("never", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("loop", withTestprocess())
+		wp.RegisterProcess("error", withErrprocess(assert.AnError), WithRestartPolicy(RestartNever), backoff)
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, wp.procs.get("error").restartCount())
+	})
+
+	t.Run("no-restart-after-stop", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp := withTestprocess()
+		wp.RegisterProcess("test", tp, WithRestartPolicy(RestartAlways), backoff)
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, tp.getRunCount(), "run count should be 1")
+		assert.Equal(t, 0, wp.procs.get("test").restartCount())
+	})
+
+	t.Run("stop-before-next-run", func(t *testing.T) {
+		wp := NewWaitProcess()
+		proc := &restartprocess{}
+		wp.RegisterProcess("loop", withTestprocess())
+		wp.RegisterProcess("test", proc, WithRestartPolicy(RestartAlways), backoff)
+		wp.Start()
+		assert.Eventually(t, func() bool {
+			return wp.Snapshot()[1].State == ProcessRunning
+		}, time.Second, time.Millisecond)
+
+		// the run exits and the next one waits for restartLock, the stop must not be lost
+		stat := wp.procs.get("test")
+		stat.restartLock.Lock()
+		proc.Stop()
+		time.Sleep(50 * time.Millisecond)
+
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- wp.StopProcess("test")
+		}()
+		time.Sleep(50 * time.Millisecond)
+		stat.restartLock.Unlock()
+
+		select {
+		case err := <-stopped:
+			assert.Nil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the stop was lost")
+		}
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+		assert.Nil(t, wp.Shutdown())
+	})
+}
@@ -2,44 +2,437 @@ package waitprocess
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
 type procstat struct {
-	ctx      context.Context
-	cancel   context.CancelFunc
-	name     string
-	proc     Process
-	panicked unsafe.Pointer
+	ctx              context.Context
+	cancel           context.CancelFunc
+	name             string
+	proc             Process
+	opt              processOption
+	panicked         unsafe.Pointer
+	stopping         int32
+	runCancel        context.CancelFunc
+	restartRequested int32
+	restartLock      sync.Mutex
+	stoppingRestart  int32
+	abandoned        int32
+	detached         int32
+	lock             sync.Mutex
+	restarts         int
+	lastRestart      time.Time
+	health           HealthStatus
+	startTime        time.Time
+	exitTime         time.Time
+	panicStack       []byte
+	lastErr          error
+	launched         bool
+	running          bool
+	restarting       bool
+	launchTime       time.Time
+	failures         int
+	panics           int
+	startDuration    histogram
+	stopDuration     histogram
+	panicPolicy      PanicPolicy
+	onPanic          func(*ProcessError)
+	events           *eventBus
+	deps             []*procstat
+	dependents       []*procstat
+	started          chan struct{}
+	ready            chan struct{}
+	readyOnce        sync.Once
+	done             chan struct{}
+	stopped          chan struct{}
+	stoppedOnce      sync.Once
 }
 
-func newProcstat(name string, proc Process) *procstat {
+func newProcstat(name string, proc Process, opt processOption) *procstat {
 	return &procstat{
-		name: name,
-		proc: proc,
+		name:    name,
+		proc:    proc,
+		opt:     opt,
+		started: make(chan struct{}),
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
 	}
 }
 
+// setContext sets the context of the process, it keeps the values of ctx but is only
+// cancelled by stop, so processes can be stopped in order. it carries the name of the process
+// and whether it's restarted
 func (p *procstat) setContext(ctx context.Context) {
-	p.ctx, p.cancel = context.WithCancel(ctx)
-	p.proc.SetContext(p.ctx)
+	ctx = context.WithValue(ctx, processNameKey{}, p.name)
+	ctx = context.WithValue(ctx, restartRequestedKey{}, p.isRestarting)
+	p.ctx, p.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	p.lock.Lock()
+	p.launched = true
+	p.launchTime = time.Now()
+	p.lock.Unlock()
+
+	if notifier, ok := p.proc.(ReadyNotifier); ok {
+		notifier.NotifyReady(p.markReady)
+	}
+}
+
+func (p *procstat) markReady() {
+	p.readyOnce.Do(func() {
+		p.lock.Lock()
+		p.startDuration.observe(time.Since(p.launchTime).Seconds())
+		p.lock.Unlock()
+		close(p.ready)
+	})
+}
+
+func (p *procstat) isReady() bool {
+	select {
+	case <-p.ready:
+		return true
+	default:
+		return false
+	}
 }
 
 func (p *procstat) getPanicked() unsafe.Pointer {
-	return p.panicked
+	return atomic.LoadPointer(&p.panicked)
+}
+
+func (p *procstat) restartCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.restarts
+}
+
+func (p *procstat) lastRestartTime() time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.lastRestart
+}
+
+// waitDependencies blocks until all dependencies are ready, returns false if the process is stopped first
+func (p *procstat) waitDependencies() bool {
+	for _, dep := range p.deps {
+		select {
+		case <-dep.ready:
+		case <-p.ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
+
+// waitDependents blocks until all processes depending on this one have exited or been abandoned
+func (p *procstat) waitDependents() {
+	for _, dependent := range p.dependents {
+		<-dependent.stopped
+	}
+}
+
+// run runs the process, and runs it again according to the restart policy
+func (p *procstat) run(log Logger) error {
+	close(p.started)
+	if _, ok := p.proc.(ReadyNotifier); !ok {
+		p.markReady()
+	}
+
+	p.restartLock.Lock()
+	for attempt := 0; ; attempt++ {
+		err := p.runOnce()
+		cause := err
+		if panicked := p.getPanicked(); panicked != nil {
+			procErr := p.newError(PhaseRun, fmt.Errorf("panic: %v", *(*any)(panicked)))
+			log.WithField("panic", procErr.Panic).Error("Process panicked")
+			if p.onPanic != nil {
+				p.onPanic(procErr)
+			}
+
+			if p.panicPolicy != PanicRestart || !p.canRestart() {
+				return procErr
+			}
+
+			atomic.StorePointer(&p.panicked, nil)
+			cause = procErr
+			if !p.waitRestart(log, attempt, procErr) {
+				return procErr
+			}
+		} else if atomic.CompareAndSwapInt32(&p.restartRequested, 1, 0) && !p.isStopping() {
+			log.WithError(err).Warn("Process restarted on request")
+		} else {
+			if !p.shouldRestart(err) {
+				return err
+			}
+
+			if !p.waitRestart(log, attempt, err) {
+				return err
+			}
+		}
+
+		if !p.lockRestart(cause) {
+			return err
+		}
+	}
 }
 
-func (p *procstat) run() error {
+// lockRestart locks restartLock for the next run and counts the restart, it returns false if
+// the process was stopped since the restart was decided. stop holds restartLock as well, so a
+// stop is either seen here or stops the next run
+func (p *procstat) lockRestart(cause error) bool {
+	p.restartLock.Lock()
+	if !p.canRestart() {
+		p.restartLock.Unlock()
+		return false
+	}
+
+	p.lock.Lock()
+	p.restarts++
+	p.lastRestart = time.Now()
+	p.lock.Unlock()
+	p.emit(Event{Type: EventProcessRestarted, Err: cause})
+	return true
+}
+
+// runOnce runs the process with a context of its own, so a single run can be restarted. it's
+// called holding restartLock, which is released once the process has the context of the run
+func (p *procstat) runOnce() (err error) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.lock.Lock()
+	p.runCancel = cancel
+	p.startTime = time.Now()
+	p.running = true
+	p.lock.Unlock()
+	p.emit(Event{Type: EventProcessStarted})
+
 	defer func() {
-		if r := recover(); r != nil {
-			p.panicked = unsafe.Pointer(&r)
+		r := recover()
+
+		p.lock.Lock()
+		p.runCancel = nil
+		p.running = false
+		p.exitTime = time.Now()
+		p.lastErr = err
+		if r != nil {
+			p.panicStack = debug.Stack()
+			p.lastErr = fmt.Errorf("panic: %v", r)
+			p.panics++
+		} else if err != nil {
+			p.failures++
+		}
+		lastErr := p.lastErr
+		p.lock.Unlock()
+		cancel()
+
+		switch {
+		case r != nil:
+			atomic.StorePointer(&p.panicked, unsafe.Pointer(&r))
+			p.emit(Event{Type: EventProcessPanicked, Err: lastErr})
+		case err != nil:
+			p.emit(Event{Type: EventProcessFailed, Err: err})
+		default:
+			p.emit(Event{Type: EventProcessExited})
 		}
+
+		if p.opt.afterExit != nil {
+			p.opt.afterExit(context.WithoutCancel(ctx), lastErr)
+		}
+	}()
+
+	// restart and stop call Stop holding restartLock, so a Stop can't be reset by SetContext
+	func() {
+		defer p.restartLock.Unlock()
+		p.proc.SetContext(ctx)
 	}()
 
+	if p.opt.beforeRun != nil {
+		if err := p.opt.beforeRun(ctx); err != nil {
+			return fmt.Errorf("before-run hook: %w", err)
+		}
+	}
+
 	return p.proc.Run()
 }
 
-func (p *procstat) stop() {
+// restart stops the current run of the process, which is then run again immediately.
+// it returns false if the process is not running
+func (p *procstat) restart() bool {
+	p.lock.Lock()
+	cancel := p.runCancel
+	p.lock.Unlock()
+
+	if cancel == nil || p.isStopping() {
+		return false
+	}
+
+	p.restartLock.Lock()
+	defer p.restartLock.Unlock()
+
+	atomic.StoreInt32(&p.restartRequested, 1)
+	atomic.StoreInt32(&p.stoppingRestart, 1)
+	defer atomic.StoreInt32(&p.stoppingRestart, 0)
+
+	cancel()
+	p.proc.Stop()
+	return true
+}
+
+// newError creates a ProcessError of err with the timing of the last run, exit time is now
+// if the process is still running
+func (p *procstat) newError(phase Phase, err error) *ProcessError {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	exitTime := p.exitTime
+	if exitTime.Before(p.startTime) {
+		exitTime = time.Now()
+	}
+
+	procErr := &ProcessError{
+		Name:      p.name,
+		Phase:     phase,
+		StartTime: p.startTime,
+		ExitTime:  exitTime,
+		Runtime:   exitTime.Sub(p.startTime),
+		Restarts:  p.restarts,
+		Err:       err,
+	}
+
+	if panicked := p.getPanicked(); panicked != nil {
+		procErr.Panic = *(*any)(panicked)
+		procErr.Stack = p.panicStack
+	}
+
+	return procErr
+}
+
+// emit emits a process event of the process
+func (p *procstat) emit(event Event) {
+	if p.events != nil {
+		event.Proc = p.name
+		p.events.emit(event)
+	}
+}
+
+func (p *procstat) isStopping() bool {
+	return atomic.LoadInt32(&p.stopping) == 1
+}
+
+// isRestarting returns true while restart stops the process
+func (p *procstat) isRestarting() bool {
+	return atomic.LoadInt32(&p.stoppingRestart) == 1
+}
+
+// waitRestart waits for the backoff delay before the next restart, returns false if the
+// process is stopped first
+func (p *procstat) waitRestart(log Logger, attempt int, err error) bool {
+	delay := p.opt.backoff.delay(attempt)
+	log.WithError(err).WithField("delay", delay).Warn("Process exited, restarting")
+
+	p.setRestarting(true)
+	defer p.setRestarting(false)
+
+	select {
+	case <-p.ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+func (p *procstat) setRestarting(restarting bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.restarting = restarting
+}
+
+func (p *procstat) canRestart() bool {
+	return !p.isStopping() && p.ctx.Err() == nil
+}
+
+func (p *procstat) shouldRestart(err error) bool {
+	return p.canRestart() && p.opt.restartPolicy.shouldRestart(err)
+}
+
+// exited returns true if the run of the process has returned
+func (p *procstat) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// detach marks the process as removed or stopped on its own, its exit doesn't stop the waitprocess
+func (p *procstat) detach() {
+	atomic.StoreInt32(&p.detached, 1)
+}
+
+func (p *procstat) isDetached() bool {
+	return atomic.LoadInt32(&p.detached) == 1
+}
+
+func (p *procstat) isAbandoned() bool {
+	return atomic.LoadInt32(&p.abandoned) == 1
+}
+
+// stopAndWait stops the process and waits for it to exit, returns false if it doesn't
+// exit within the stop timeout, the process is then marked abandoned. a process already
+// stopped is only waited for
+func (p *procstat) stopAndWait() bool {
+	defer p.stoppedOnce.Do(func() {
+		close(p.stopped)
+	})
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		stopTime := time.Now()
+		if !p.stop() {
+			<-p.done
+			return
+		}
+		<-p.done
+
+		p.lock.Lock()
+		p.stopDuration.observe(time.Since(stopTime).Seconds())
+		p.lock.Unlock()
+	}()
+
+	if p.opt.stopTimeout <= 0 {
+		<-exited
+		return true
+	}
+
+	timer := time.NewTimer(p.opt.stopTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-exited:
+		return true
+	case <-timer.C:
+		atomic.StoreInt32(&p.abandoned, 1)
+		return false
+	}
+}
+
+// stop stops the process, it returns false if the process was already stopped
+func (p *procstat) stop() bool {
+	p.restartLock.Lock()
+	defer p.restartLock.Unlock()
+
+	if !atomic.CompareAndSwapInt32(&p.stopping, 0, 1) {
+		return false
+	}
+
 	defer p.cancel()
 	p.proc.Stop()
+	return true
 }
@@ -7,7 +7,9 @@ type stopFuncProcess struct {
 	stopFunc func()
 }
 
-// RunWithStopFunc create a Process with runFunc and stopFunc, call stop to stop runFunc
+// RunWithStopFunc create a Process with runFunc and stopFunc, call stop to stop runFunc.
+// runFunc is called again when the process is restarted in place, stopFunc must not prevent it
+// from running again, e.g. by closing a server that can't be served again
 func RunWithStopFunc(runFunc func() error, stopFunc func()) Process {
 	return &stopFuncProcess{
 		runFunc:  runFunc,
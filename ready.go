@@ -0,0 +1,83 @@
+package waitprocess
+
+import (
+	"context"
+	"time"
+)
+
+// Ready returns a channel that is closed when all processes are ready
+func (wp *WaitProcess) Ready() <-chan struct{} {
+	return wp.readyChan
+}
+
+// WaitReady waits for all processes to be ready, it returns an error if the waitprocess
+// stops first or ctx is done
+func (wp *WaitProcess) WaitReady(ctx context.Context) error {
+	if wp.getState() != stateStarted {
+		wp.panicf("Cannot call WaitReady() before WaitProcess has started")
+	}
+
+	select {
+	case <-wp.readyChan:
+		return nil
+	case <-wp.ctx.Done():
+	case <-wp.stopChan:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-wp.readyChan:
+		return nil
+	default:
+	}
+
+	if err := wp.getError(); err != nil {
+		return err
+	}
+	return ErrStoppedBeforeReady
+}
+
+// watchReady closes readyChan once all processes are ready, and fails the waitprocess
+// if they are not ready within the startup timeout
+func (wp *WaitProcess) watchReady(order []*procstat) {
+	var deadline <-chan time.Time
+	if wp.startupTimeout > 0 {
+		timer := time.NewTimer(wp.startupTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for _, proc := range order {
+		select {
+		case <-proc.ready:
+		case <-proc.done:
+			// processes that exited without stopping the waitprocess don't hold back readiness
+			if wp.ctx.Err() != nil {
+				return
+			}
+		case <-wp.ctx.Done():
+			return
+		case <-wp.stopChan:
+			return
+		case <-deadline:
+			unready := make([]string, 0)
+			for _, proc := range order {
+				if !proc.isReady() {
+					unready = append(unready, proc.name)
+				}
+			}
+
+			startupErr := &StartupTimeoutError{Timeout: wp.startupTimeout, Procs: unready}
+			wp.addError(startupErr)
+			wp.setStopReason(StopReason{Kind: StopReasonStartupTimeout, Err: startupErr})
+			wp.log.WithField("procs", unready).Error("Startup timeout, processes not ready")
+			wp.cancel()
+			return
+		}
+	}
+
+	close(wp.readyChan)
+	wp.log.Debug("All processes ready")
+	wp.runPostStartHooks()
+}
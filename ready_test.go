@@ -0,0 +1,131 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWaitReady(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		wp := NewWaitProcess()
+		readyCh := make(chan struct{})
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("ready", RunWithReady(func(ctx context.Context, ready func()) error {
+			<-readyCh
+			ready()
+			<-ctx.Done()
+			return nil
+		}))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		select {
+		case <-wp.Ready():
+			assert.Fail(t, "wp should not be ready")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(readyCh)
+		err := wp.WaitReady(context.Background())
+		assert.Nil(t, err)
+	})
+
+	t.Run("ctx-done", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("never-ready", RunWithReady(func(ctx context.Context, ready func()) error {
+			<-ctx.Done()
+			return nil
+		}))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := wp.WaitReady(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("stopped-before-ready", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("never-ready", RunWithReady(func(ctx context.Context, ready func()) error {
+			<-ctx.Done()
+			return nil
+		}))
+
+		wp.Start()
+		wp.Stop()
+		err := wp.WaitReady(context.Background())
+		assert.ErrorIs(t, err, ErrStoppedBeforeReady)
+	})
+
+	t.Run("error-before-ready", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", RunWithReady(func(ctx context.Context, ready func()) error {
+			return assert.AnError
+		}))
+
+		wp.Start()
+		err := wp.WaitReady(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("wait-ready-before-start", func(t *testing.T) {
+		wp := NewWaitProcess()
+		assert.Panics(t, func() {
+			wp.WaitReady(context.Background())
+		})
+	})
+}
+
+func TestWithStartupTimeout(t *testing.T) {
+	wp := NewWaitProcess(WithStartupTimeout(100 * time.Millisecond))
+	wp.RegisterProcess("test", withTestprocess())
+	wp.RegisterProcess("never-ready", RunWithReady(func(ctx context.Context, ready func()) error {
+		<-ctx.Done()
+		return nil
+	}))
+
+	wp.Start()
+	err := wp.Wait(time.Second)
+
+	var startupErr *StartupTimeoutError
+	assert.ErrorAs(t, err, &startupErr)
+	assert.Equal(t, []string{"never-ready"}, startupErr.Procs)
+}
+
+func TestDependsOnReady(t *testing.T) {
+	wp := NewWaitProcess()
+	recorder := &testrecorder{}
+	readyCh := make(chan struct{})
+
+	wp.RegisterProcess("http", RunWithCtx(func(ctx context.Context) error {
+		recorder.add("start http")
+		<-ctx.Done()
+		return nil
+	}), DependsOn("db"))
+	wp.RegisterProcess("db", RunWithReady(func(ctx context.Context, ready func()) error {
+		<-readyCh
+		recorder.add("db ready")
+		ready()
+		<-ctx.Done()
+		return nil
+	}))
+
+	wp.Start()
+	defer wp.Shutdown()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, recorder.get(), "http should wait for db to be ready")
+
+	close(readyCh)
+	err := wp.WaitReady(context.Background())
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"db ready", "start http"}, recorder.get())
+}
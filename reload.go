@@ -0,0 +1,63 @@
+package waitprocess
+
+import (
+	"context"
+	"os"
+)
+
+// Reload reloads the running processes implementing Reloadable in start order. a failed
+// reload doesn't stop the reload of the others nor the waitprocess, unless set by
+// WithStopOnReloadFailure. the failures are returned as a MultiError of PhaseError
+func (wp *WaitProcess) Reload(ctx context.Context) error {
+	if wp.getState() != stateStarted {
+		wp.panicf("Cannot call Reload() before WaitProcess has started")
+	}
+
+	wp.reloadLock.Lock()
+	defer wp.reloadLock.Unlock()
+
+	wp.lock.Lock()
+	order := append([]*procstat{}, wp.order...)
+	wp.lock.Unlock()
+
+	errs := make([]error, 0)
+	for _, proc := range order {
+		reloadable, ok := proc.proc.(Reloadable)
+		if !ok || proc.exited() || proc.isStopping() {
+			continue
+		}
+
+		log := wp.log.WithField("proc", proc.name)
+		err := reloadable.Reload(ctx)
+		wp.events.emit(Event{Type: EventProcessReloaded, Proc: proc.name, Err: err})
+		if err == nil {
+			log.Info("Process reloaded")
+			continue
+		}
+
+		log.WithError(err).Error("Process failed to reload")
+		reloadErr := &PhaseError{Name: proc.name, Phase: PhaseReload, Err: err}
+		errs = append(errs, reloadErr)
+
+		if wp.stopOnReload {
+			wp.addError(reloadErr)
+			wp.setStopReason(StopReason{Kind: StopReasonReload, Proc: proc.name, Err: err})
+			wp.cancel()
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: errs}
+}
+
+func (wp *WaitProcess) reloadOnSignal(ctx context.Context, sig os.Signal) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	wp.log.WithField("signal", sig).Info("Reloading processes")
+	wp.Reload(ctx)
+}
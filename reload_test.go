@@ -0,0 +1,83 @@
+package waitprocess
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	t.Run("reloadable", func(t *testing.T) {
+		wp := NewWaitProcess()
+		proc := withReloadprocess(nil)
+		wp.RegisterProcess("reload", proc)
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		defer wp.Shutdown()
+
+		assert.Nil(t, wp.Reload(context.Background()))
+		assert.Nil(t, wp.Reload(context.Background()))
+		assert.Equal(t, 2, proc.getReloadCount())
+	})
+
+	t.Run("failure-keeps-running", func(t *testing.T) {
+		wp := NewWaitProcess()
+		failing := withReloadprocess(assert.AnError)
+		proc := withReloadprocess(nil)
+		wp.RegisterProcess("failing", failing)
+		wp.RegisterProcess("reload", proc)
+		wp.Start()
+
+		err := wp.Reload(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+
+		var phaseErr *PhaseError
+		assert.True(t, errors.As(err, &phaseErr))
+		assert.Equal(t, "failing", phaseErr.Name)
+		assert.Equal(t, PhaseReload, phaseErr.Phase)
+
+		assert.Equal(t, 1, proc.getReloadCount())
+		assert.False(t, wp.Stopped())
+		assert.Equal(t, StopReasonNone, wp.StopReason().Kind)
+		assert.Nil(t, wp.Shutdown())
+	})
+
+	t.Run("stop-on-failure", func(t *testing.T) {
+		wp := NewWaitProcess(WithStopOnReloadFailure(true))
+		wp.RegisterProcess("failing", withReloadprocess(assert.AnError))
+		wp.Start()
+
+		wp.Reload(context.Background())
+		err := wp.Wait()
+		assert.ErrorIs(t, err, assert.AnError)
+
+		reason := wp.StopReason()
+		assert.Equal(t, StopReasonReload, reason.Kind)
+		assert.Equal(t, "failing", reason.Proc)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("failing", withReloadprocess(assert.AnError))
+		wp.RegisterProcess("reload", withReloadprocess(nil))
+		ch := wp.Events()
+		wp.Start()
+
+		wp.Reload(context.Background())
+		wp.Shutdown()
+
+		reloaded := make([]Event, 0)
+		for event := range ch {
+			if event.Type == EventProcessReloaded {
+				reloaded = append(reloaded, event)
+			}
+		}
+
+		assert.Len(t, reloaded, 2)
+		assert.Equal(t, "failing", reloaded[0].Proc)
+		assert.ErrorIs(t, reloaded[0].Err, assert.AnError)
+		assert.Equal(t, "reload", reloaded[1].Proc)
+		assert.Nil(t, reloaded[1].Err)
+	})
+}
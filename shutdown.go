@@ -0,0 +1,153 @@
+package waitprocess
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutdown stops the processes and runs the after-stop hooks, bounded by the shutdown timeout.
+// when the processes overrun the timeout, stopChan is closed and the after-stop hooks run in
+// degraded mode: in the background, without being waited for
+func (wp *WaitProcess) shutdown(order []*procstat) {
+	// the waitprocess context is cancelled by now, hooks get its values only
+	hookCtx := context.WithoutCancel(wp.ctx)
+
+	var deadline <-chan time.Time
+	if wp.shutdownTimeout > 0 {
+		timer := time.NewTimer(wp.shutdownTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+
+		var cancel context.CancelFunc
+		hookCtx, cancel = context.WithTimeout(hookCtx, wp.shutdownTimeout)
+		defer cancel()
+	}
+
+	var abandoned []string
+	procsStopped := make(chan struct{})
+	go func() {
+		defer close(procsStopped)
+		wp.runPreStopHooks(hookCtx)
+		abandoned = wp.stopProcs(order)
+	}()
+
+	select {
+	case <-procsStopped:
+		wp.abandoned = abandoned
+	case <-deadline:
+		running := make([]string, 0)
+		for _, proc := range order {
+			if !proc.exited() {
+				running = append(running, proc.name)
+			}
+		}
+
+		wp.shutdownErr = &ShutdownTimeoutError{Timeout: wp.shutdownTimeout, Procs: running}
+		wp.log.WithField("procs", running).Error("Shutdown timeout, processes still running")
+		wp.events.emit(Event{Type: EventGroupStopped})
+		close(wp.stopChan)
+
+		go func() {
+			wp.runAfterStopHooks(context.WithoutCancel(wp.ctx), true, nil)
+			wp.events.close()
+		}()
+		return
+	}
+
+	var finished int32
+	hooksDone := make(chan struct{})
+	go func() {
+		defer close(hooksDone)
+		wp.runAfterStopHooks(hookCtx, false, &finished)
+	}()
+
+	select {
+	case <-hooksDone:
+	case <-deadline:
+		running := make([]string, 0)
+		wp.afterStopHooks.rangeFunc(func(index int, name string, _ hook) bool {
+			if index >= int(atomic.LoadInt32(&finished)) {
+				running = append(running, name)
+			}
+			return true
+		})
+
+		wp.shutdownErr = &ShutdownTimeoutError{Timeout: wp.shutdownTimeout, Hooks: running}
+		wp.log.WithField("hooks", running).Error("Shutdown timeout, after-stop hooks still running")
+	}
+
+	wp.events.emit(Event{Type: EventGroupStopped})
+	wp.events.close()
+	close(wp.stopChan)
+}
+
+// runAfterStopHooks runs the after-stop hooks in order and adds their errors, finished counts
+// the hooks that returned
+func (wp *WaitProcess) runAfterStopHooks(ctx context.Context, degraded bool, finished *int32) {
+	wp.afterStopHooks.rangeFunc(func(index int, name string, value hook) bool {
+		log := wp.log.WithField("hook", name)
+		if degraded {
+			log.Warn("Running after-stop hook in degraded mode")
+		} else {
+			log.Debug("Running after-stop hook")
+		}
+
+		if err := wp.runHook(ctx, value); err != nil {
+			wp.addError(err)
+		}
+
+		if finished != nil {
+			atomic.AddInt32(finished, 1)
+		}
+		return true
+	})
+}
+
+// stopProcs stops the processes according to the shutdown order and waits for them to exit,
+// order is the start order. processes that don't exit within their stop timeout are abandoned
+func (wp *WaitProcess) stopProcs(order []*procstat) (abandoned []string) {
+	if wp.shutdownOrder.sequential() {
+		registered := make([]*procstat, 0, wp.procs.size())
+		wp.procs.rangeFunc(func(_ int, _ string, proc *procstat) bool {
+			registered = append(registered, proc)
+			return true
+		})
+
+		for _, proc := range wp.shutdownOrder.sort(registered) {
+			wp.stopProc(proc)
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		wg.Add(len(order))
+
+		// dependents are stopped before their dependencies
+		for i := len(order) - 1; i >= 0; i-- {
+			proc := order[i]
+			go func() {
+				defer wg.Done()
+				proc.waitDependents()
+				wp.stopProc(proc)
+			}()
+		}
+
+		wg.Wait()
+	}
+
+	for _, proc := range order {
+		if proc.isAbandoned() {
+			abandoned = append(abandoned, proc.name)
+		}
+	}
+
+	return abandoned
+}
+
+func (wp *WaitProcess) stopProc(proc *procstat) {
+	log := wp.log.WithField("proc", proc.name)
+	log.Debug("Stopping process")
+	if !proc.stopAndWait() {
+		log.WithField("timeout", proc.opt.stopTimeout).Error("Process failed to stop in time, abandoned")
+	}
+}
@@ -0,0 +1,49 @@
+package waitprocess
+
+// ShutdownOrder decides the order in which processes are stopped
+type ShutdownOrder int
+
+const (
+	// ShutdownConcurrent stops all processes at once, only dependents are waited for
+	ShutdownConcurrent ShutdownOrder = iota
+	// ShutdownReverse stops processes one by one in reverse registration order
+	ShutdownReverse
+	// ShutdownRegistration stops processes one by one in registration order
+	ShutdownRegistration
+)
+
+func (o ShutdownOrder) String() string {
+	switch o {
+	case ShutdownConcurrent:
+		return "concurrent"
+	case ShutdownReverse:
+		return "reverse"
+	case ShutdownRegistration:
+		return "registration"
+	default:
+		return "unknown"
+	}
+}
+
+func (o ShutdownOrder) sequential() bool {
+	return o == ShutdownReverse || o == ShutdownRegistration
+}
+
+// sort returns the processes in the order they are stopped, registered is in registration order.
+// dependents are always stopped before their dependencies
+func (o ShutdownOrder) sort(registered []*procstat) []*procstat {
+	procs := make([]*procstat, len(registered))
+	copy(procs, registered)
+
+	if o != ShutdownRegistration {
+		for i, j := 0, len(procs)-1; i < j; i, j = i+1, j-1 {
+			procs[i], procs[j] = procs[j], procs[i]
+		}
+	}
+
+	order, _ := sortProcs(procs, func(proc *procstat) []*procstat {
+		return proc.dependents
+	})
+
+	return order
+}
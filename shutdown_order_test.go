@@ -0,0 +1,101 @@
+package waitprocess
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder_sort(t *testing.T) {
+	names := func(order []*procstat) []string {
+		result := make([]string, 0, len(order))
+		for _, proc := range order {
+			result = append(result, proc.name)
+		}
+		return result
+	}
+
+	newProcs := func() []*procstat {
+		procs := newOrderMap[string, *procstat]()
+		procs.set("a", newProcstat("a", withTestprocess(), newProcessOption()))
+		procs.set("b", newProcstat("b", withTestprocess(), newProcessOption(DependsOn("c"))))
+		procs.set("c", newProcstat("c", withTestprocess(), newProcessOption()))
+		procs.set("d", newProcstat("d", withTestprocess(), newProcessOption()))
+
+		_, err := resolveDependencies(procs)
+		assert.Nil(t, err)
+
+		registered := make([]*procstat, 0)
+		procs.rangeFunc(func(_ int, _ string, proc *procstat) bool {
+			registered = append(registered, proc)
+			return true
+		})
+		return registered
+	}
+
+	t.Run("reverse", func(t *testing.T) {
+		assert.Equal(t, []string{"d", "b", "c", "a"}, names(ShutdownReverse.sort(newProcs())))
+	})
+
+	t.Run("registration", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b", "c", "d"}, names(ShutdownRegistration.sort(newProcs())))
+	})
+}
+
+func TestWithShutdownOrder(t *testing.T) {
+	newProc := func(recorder *testrecorder, name string) Process {
+		ch := make(chan struct{})
+		return RunWithStopFunc(
+			func() error {
+				<-ch
+				// simulate slow cleanup, the next process must not be stopped yet
+				time.Sleep(10 * time.Millisecond)
+				recorder.add("exit " + name)
+				return nil
+			},
+			func() {
+				recorder.add("stop " + name)
+				close(ch)
+			},
+		)
+	}
+
+	t.Run("reverse", func(t *testing.T) {
+		recorder := &testrecorder{}
+		wp := NewWaitProcess(WithShutdownOrder(ShutdownReverse))
+		wp.RegisterProcess("queue", newProc(recorder, "queue"))
+		wp.RegisterProcess("worker", newProc(recorder, "worker"))
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"stop worker", "exit worker", "stop queue", "exit queue"}, recorder.get())
+	})
+
+	t.Run("registration", func(t *testing.T) {
+		recorder := &testrecorder{}
+		wp := NewWaitProcess(WithShutdownOrder(ShutdownRegistration))
+		wp.RegisterProcess("worker", newProc(recorder, "worker"))
+		wp.RegisterProcess("queue", newProc(recorder, "queue"))
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"stop worker", "exit worker", "stop queue", "exit queue"}, recorder.get())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		recorder := &testrecorder{}
+		wp := NewWaitProcess(WithShutdownOrder(ShutdownConcurrent))
+		wp.RegisterProcess("worker", newProc(recorder, "worker"))
+		wp.RegisterProcess("queue", newProc(recorder, "queue"))
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+
+		records := recorder.get()
+		assert.ElementsMatch(t, []string{"stop worker", "stop queue"}, records[:2])
+		assert.ElementsMatch(t, []string{"exit worker", "exit queue"}, records[2:])
+	})
+}
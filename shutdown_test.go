@@ -0,0 +1,86 @@
+package waitprocess
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWithShutdownTimeout(t *testing.T) {
+	t.Run("processes-overrun", func(t *testing.T) {
+		wp := NewWaitProcess(WithShutdownTimeout(100 * time.Millisecond))
+		hang := make(chan struct{})
+		defer close(hang)
+
+		hooked := make(chan struct{})
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("hang", RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {}))
+		wp.AfterStopHook("hook", func() {
+			close(hooked)
+		})
+
+		wp.Start()
+		err := wp.Shutdown(time.Second)
+
+		var shutdownErr *ShutdownTimeoutError
+		assert.ErrorAs(t, err, &shutdownErr)
+		assert.Equal(t, []string{"hang"}, shutdownErr.Procs)
+		assert.Empty(t, shutdownErr.Hooks)
+		assert.True(t, wp.Stopped(), "wp should be stopped")
+
+		select {
+		case <-hooked:
+		case <-time.After(time.Second):
+			assert.Fail(t, "after-stop hook should run in degraded mode")
+		}
+	})
+
+	t.Run("hooks-overrun", func(t *testing.T) {
+		wp := NewWaitProcess(WithShutdownTimeout(100 * time.Millisecond))
+		hang := make(chan struct{})
+		defer close(hang)
+
+		stat := &teststate{}
+		wp.RegisterProcess("test", withTestprocess())
+		wp.AfterStopHook("hook1", func() {
+			stat.add()
+		})
+		wp.AfterStopHook("hook2", func() {
+			<-hang
+		})
+		wp.AfterStopHook("hook3", func() {
+			stat.add()
+		})
+
+		wp.Start()
+		err := wp.Shutdown(time.Second)
+
+		var shutdownErr *ShutdownTimeoutError
+		assert.ErrorAs(t, err, &shutdownErr)
+		assert.Empty(t, shutdownErr.Procs)
+		assert.Equal(t, []string{"hook2", "hook3"}, shutdownErr.Hooks)
+		assert.Equal(t, 1, stat.getstate(), "only hook1 should be finished")
+	})
+
+	t.Run("in-time", func(t *testing.T) {
+		wp := NewWaitProcess(WithShutdownTimeout(time.Second))
+		stat := &teststate{}
+		wp.RegisterProcess("test", withTestprocess())
+		wp.AfterStopHook("hook", func() {
+			stat.add()
+		})
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, stat.getstate(), "after-stop hook should be finished")
+	})
+}
+
+func TestShutdownTimeoutError(t *testing.T) {
+	err := &ShutdownTimeoutError{Timeout: time.Second, Procs: []string{"a", "b"}, Hooks: []string{"c"}}
+	assert.EqualError(t, err, "shutdown timeout after 1s, still running: processes a, b; after-stop hooks c")
+}
@@ -0,0 +1,130 @@
+package waitprocess
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// SignalAction is what the waitprocess does when it receives a signal
+type SignalAction int
+
+const (
+	// SignalStop stops the waitprocess
+	SignalStop SignalAction = iota
+	// SignalReload reloads the processes implementing Reloadable
+	SignalReload
+	// SignalDump writes a diagnostic dump to the dump writer
+	SignalDump
+)
+
+func (a SignalAction) String() string {
+	switch a {
+	case SignalStop:
+		return "stop"
+	case SignalReload:
+		return "reload"
+	case SignalDump:
+		return "dump"
+	default:
+		return "unknown"
+	}
+}
+
+// SignalHandler handles a signal received by the waitprocess, ctx is done when the
+// waitprocess stops
+type SignalHandler func(ctx context.Context, sig os.Signal)
+
+type signalAction struct {
+	stop   bool
+	handle SignalHandler
+}
+
+// RegisterSignalAction maps signals to an action, a signal registered again is remapped
+func (wp *WaitProcess) RegisterSignalAction(action SignalAction, sigs ...os.Signal) *WaitProcess {
+	switch action {
+	case SignalStop:
+		return wp.registerSignal(signalAction{stop: true}, sigs...)
+	case SignalReload:
+		return wp.registerSignal(signalAction{handle: wp.reloadOnSignal}, sigs...)
+	case SignalDump:
+		return wp.registerSignal(signalAction{handle: wp.dumpOnSignal}, sigs...)
+	default:
+		wp.panicf("Unknown signal action %v", action)
+		return wp
+	}
+}
+
+// RegisterSignalHandler calls the handler when one of the signals is received, the
+// waitprocess keeps running. handlers run in a goroutine of their own
+func (wp *WaitProcess) RegisterSignalHandler(handler SignalHandler, sigs ...os.Signal) *WaitProcess {
+	return wp.registerSignal(signalAction{handle: handler}, sigs...)
+}
+
+func (wp *WaitProcess) registerSignal(action signalAction, sigs ...os.Signal) *WaitProcess {
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+
+	if wp.state != stateReady {
+		wp.panicf("Cannot call RegisterSignal() after WaitProcess has already started")
+	}
+
+	for _, sig := range sigs {
+		wp.signals[sig] = action
+	}
+
+	signal.Notify(wp.signalChan, sigs...)
+	return wp
+}
+
+// watchStop waits for a stop signal, the context or the timer, then shuts the waitprocess
+// down. signals mapped to other actions are handled meanwhile, and during the shutdown
+func (wp *WaitProcess) watchStop() {
+	var timer <-chan time.Time
+
+	if wp.timer != nil {
+		timer = wp.timer.C
+	} else {
+		tch := make(chan time.Time)
+		defer close(tch)
+		timer = tch
+	}
+
+	for stopped := false; !stopped; {
+		select {
+		case sig := <-wp.signalChan:
+			wp.events.emit(Event{Type: EventSignalReceived, Signal: sig})
+
+			action := wp.signals[sig]
+			if !action.stop {
+				wp.log.WithField("signal", sig).Debug("Received signal, handling it")
+				go action.handle(wp.ctx, sig)
+				continue
+			}
+
+			wp.log.Debug("Received signal, stopping WaitProcess")
+			wp.setStopReason(StopReason{Kind: StopReasonSignal, Signal: sig})
+		case <-wp.ctx.Done():
+			wp.log.Debug("Context done, stopping WaitProcess")
+			wp.setStopReason(StopReason{Kind: StopReasonContext, Err: context.Cause(wp.ctx)})
+		case <-timer:
+			wp.log.Debug("Timer done, stopping WaitProcess")
+			wp.setStopReason(StopReason{Kind: StopReasonTimer})
+		}
+		stopped = true
+	}
+
+	wp.lock.Lock()
+	wp.closing = true
+	order := append([]*procstat{}, wp.order...)
+	wp.lock.Unlock()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		wp.shutdown(order)
+	}()
+
+	wp.watchEscalation(order, shutdownDone)
+}
@@ -0,0 +1,98 @@
+package waitprocess
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type syncbuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncbuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncbuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestRegisterSignalAction(t *testing.T) {
+	t.Run("reload", func(t *testing.T) {
+		wp := NewWaitProcess()
+		proc := withReloadprocess(nil)
+		wp.RegisterProcess("test", proc)
+		wp.RegisterSignalAction(SignalReload, syscall.SIGHUP)
+		wp.Start()
+		defer wp.Shutdown()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+		assert.Eventually(t, func() bool {
+			return proc.getReloadCount() == 1
+		}, time.Second, time.Millisecond)
+		assert.False(t, wp.Stopped())
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		buf := &syncbuffer{}
+		wp := NewWaitProcess(WithDumpWriter(buf))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterSignalAction(SignalDump, syscall.SIGUSR1)
+		wp.Start()
+		defer wp.Shutdown()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		assert.Eventually(t, func() bool {
+			return bytes.Contains([]byte(buf.String()), []byte("goroutine "))
+		}, time.Second, time.Millisecond)
+
+		dump := buf.String()
+		assert.Contains(t, dump, "NAME")
+		assert.Contains(t, dump, "test")
+		assert.Contains(t, dump, "running")
+		assert.False(t, wp.Stopped())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterSignalAction(SignalStop, syscall.SIGUSR2)
+		wp.Start()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		assert.Nil(t, wp.Wait(time.Second))
+		assert.Equal(t, StopReasonSignal, wp.StopReason().Kind)
+	})
+}
+
+func TestRegisterSignalHandler(t *testing.T) {
+	wp := NewWaitProcess()
+	wp.RegisterProcess("test", withTestprocess())
+
+	received := make(chan os.Signal, 1)
+	wp.RegisterSignalHandler(func(ctx context.Context, sig os.Signal) {
+		received <- sig
+	}, syscall.SIGUSR2)
+	wp.Start()
+	defer wp.Shutdown()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	select {
+	case sig := <-received:
+		assert.Equal(t, syscall.SIGUSR2, sig)
+	case <-time.After(time.Second):
+		t.Fatal("signal handler not called")
+	}
+	assert.False(t, wp.Stopped())
+}
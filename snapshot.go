@@ -0,0 +1,121 @@
+package waitprocess
+
+import "time"
+
+// ProcessState is the lifecycle state of a process
+type ProcessState int
+
+const (
+	// ProcessPending means the process is registered but the waitprocess has not started
+	ProcessPending ProcessState = iota
+	// ProcessStarting means the process waits for its dependencies or is not ready yet
+	ProcessStarting
+	// ProcessRunning means the process is running and ready
+	ProcessRunning
+	// ProcessStopping means the process was asked to stop and has not exited yet
+	ProcessStopping
+	// ProcessStopped means the process exited without error
+	ProcessStopped
+	// ProcessFailed means the process exited with an error or panicked
+	ProcessFailed
+	// ProcessRestarting means the process exited and waits to be restarted
+	ProcessRestarting
+)
+
+func (s ProcessState) String() string {
+	switch s {
+	case ProcessPending:
+		return "pending"
+	case ProcessStarting:
+		return "starting"
+	case ProcessRunning:
+		return "running"
+	case ProcessStopping:
+		return "stopping"
+	case ProcessStopped:
+		return "stopped"
+	case ProcessFailed:
+		return "failed"
+	case ProcessRestarting:
+		return "restarting"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcessStatus is the status of a process at the time of the snapshot
+type ProcessStatus struct {
+	Name  string
+	State ProcessState
+	// StartTime is the start of the current, or last, run of the process
+	StartTime time.Time
+	// Uptime is the time since StartTime while the process is running, zero otherwise
+	Uptime    time.Duration
+	Restarts  int
+	LastError error
+	// Health is nil if the process doesn't implement HealthChecker
+	Health *HealthStatus
+	Ready  bool
+	// ExitPolicy tells whether the exit of the process stops the waitprocess
+	ExitPolicy ExitPolicy
+}
+
+// Snapshot returns the status of every process in registration order
+func (wp *WaitProcess) Snapshot() []ProcessStatus {
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+
+	snapshot := make([]ProcessStatus, 0, wp.procs.size())
+	wp.procs.rangeFunc(func(_ int, _ string, proc *procstat) bool {
+		snapshot = append(snapshot, proc.status())
+		return true
+	})
+
+	return snapshot
+}
+
+func (p *procstat) status() ProcessStatus {
+	ready := p.isReady()
+	exited := p.exited()
+	stopping := p.isStopping()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	status := ProcessStatus{
+		Name:       p.name,
+		StartTime:  p.startTime,
+		Restarts:   p.restarts,
+		LastError:  p.lastErr,
+		Ready:      ready,
+		ExitPolicy: p.opt.exitPolicy,
+	}
+
+	if _, ok := p.proc.(HealthChecker); ok {
+		health := p.health
+		status.Health = &health
+	}
+
+	switch {
+	case !p.launched:
+		status.State = ProcessPending
+	case exited && p.lastErr != nil:
+		status.State = ProcessFailed
+	case exited:
+		status.State = ProcessStopped
+	case stopping:
+		status.State = ProcessStopping
+	case p.restarting:
+		status.State = ProcessRestarting
+	case p.running && ready:
+		status.State = ProcessRunning
+	default:
+		status.State = ProcessStarting
+	}
+
+	if p.running {
+		status.Uptime = time.Since(p.startTime)
+	}
+
+	return status
+}
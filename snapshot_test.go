@@ -0,0 +1,121 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Run("pending", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test1", withTestprocess())
+		wp.RegisterProcess("test2", withTestprocess(), WithExitPolicy(IgnoreExit))
+
+		snapshot := wp.Snapshot()
+		assert.Len(t, snapshot, 2)
+		assert.Equal(t, "test1", snapshot[0].Name)
+		assert.Equal(t, ProcessPending, snapshot[0].State)
+		assert.Equal(t, "test2", snapshot[1].Name)
+		assert.Equal(t, ProcessPending, snapshot[1].State)
+		assert.Equal(t, StopGroupOnExit, snapshot[0].ExitPolicy)
+		assert.Equal(t, IgnoreExit, snapshot[1].ExitPolicy)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		wp := NewWaitProcess()
+		readyCh := make(chan struct{})
+		failCh := make(chan struct{})
+		stat := &teststate{}
+
+		wp.RegisterProcess("running", withHealthprocess(), WithHealthCheck(HealthCheck{Interval: 10 * time.Millisecond}))
+		wp.RegisterProcess("starting", RunWithReady(func(ctx context.Context, ready func()) error {
+			<-readyCh
+			ready()
+			<-ctx.Done()
+			return nil
+		}))
+		wp.RegisterProcess("waiting", withTestprocess(), DependsOn("starting"))
+		wp.RegisterProcess("restarting", RunWithCtx(func(ctx context.Context) error {
+			stat.add()
+			if stat.getstate() == 1 {
+				return assert.AnError
+			}
+			<-ctx.Done()
+			return nil
+		}), WithRestartPolicy(RestartOnFailure), WithBackoff(Backoff{Initial: time.Hour}))
+		wp.RegisterProcess("failed", RunWithCtx(func(ctx context.Context) error {
+			<-failCh
+			return assert.AnError
+		}), WithExitPolicy(IgnoreExit))
+
+		wp.Start()
+		defer wp.Shutdown()
+
+		close(failCh)
+		time.Sleep(100 * time.Millisecond)
+
+		snapshot := wp.Snapshot()
+		states := make(map[string]ProcessStatus)
+		for _, status := range snapshot {
+			states[status.Name] = status
+		}
+
+		assert.Equal(t, ProcessRunning, states["running"].State)
+		assert.True(t, states["running"].Ready)
+		assert.Greater(t, states["running"].Uptime, time.Duration(0))
+		assert.NotNil(t, states["running"].Health)
+		assert.True(t, states["running"].Health.Healthy)
+
+		assert.Equal(t, ProcessStarting, states["starting"].State)
+		assert.False(t, states["starting"].Ready)
+		assert.Nil(t, states["starting"].Health)
+
+		assert.Equal(t, ProcessStarting, states["waiting"].State)
+
+		assert.Equal(t, ProcessRestarting, states["restarting"].State)
+		assert.ErrorIs(t, states["restarting"].LastError, assert.AnError)
+
+		assert.Equal(t, ProcessFailed, states["failed"].State)
+		assert.ErrorIs(t, states["failed"].LastError, assert.AnError)
+		assert.Equal(t, time.Duration(0), states["failed"].Uptime)
+
+		close(readyCh)
+		time.Sleep(100 * time.Millisecond)
+
+		snapshot = wp.Snapshot()
+		assert.Equal(t, ProcessRunning, snapshot[1].State)
+		assert.Equal(t, ProcessRunning, snapshot[2].State)
+	})
+
+	t.Run("stopping-and-stopped", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hang := make(chan struct{})
+		stopped := make(chan struct{})
+
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("hang", RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {
+			close(stopped)
+		}))
+
+		wp.Start()
+		wp.Stop()
+		<-stopped
+		time.Sleep(50 * time.Millisecond)
+
+		snapshot := wp.Snapshot()
+		assert.Equal(t, ProcessStopped, snapshot[0].State)
+		assert.Equal(t, ProcessStopping, snapshot[1].State)
+
+		close(hang)
+		err := wp.Wait()
+		assert.Nil(t, err)
+
+		snapshot = wp.Snapshot()
+		assert.Equal(t, ProcessStopped, snapshot[1].State)
+	})
+}
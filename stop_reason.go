@@ -0,0 +1,139 @@
+package waitprocess
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"unsafe"
+)
+
+// StopReasonKind is the kind of event that stopped the waitprocess
+type StopReasonKind int
+
+const (
+	// StopReasonNone means the waitprocess is not stopping
+	StopReasonNone StopReasonKind = iota
+	// StopReasonSignal means a registered signal was received
+	StopReasonSignal
+	// StopReasonContext means the context given by WithContext was cancelled
+	StopReasonContext
+	// StopReasonTimer means the timer given by WithTimer expired
+	StopReasonTimer
+	// StopReasonStop means Stop or Shutdown was called
+	StopReasonStop
+	// StopReasonProcessExit means a process returned without error
+	StopReasonProcessExit
+	// StopReasonProcessError means a process returned an error
+	StopReasonProcessError
+	// StopReasonProcessPanic means a process panicked
+	StopReasonProcessPanic
+	// StopReasonHealthCheck means a process failed its health checks
+	StopReasonHealthCheck
+	// StopReasonStartupTimeout means processes were not ready within the startup timeout
+	StopReasonStartupTimeout
+	// StopReasonHook means a pre-start or a post-start hook failed
+	StopReasonHook
+	// StopReasonReload means the reload of a process failed, see WithStopOnReloadFailure
+	StopReasonReload
+)
+
+func (k StopReasonKind) String() string {
+	switch k {
+	case StopReasonNone:
+		return "none"
+	case StopReasonSignal:
+		return "signal"
+	case StopReasonContext:
+		return "context"
+	case StopReasonTimer:
+		return "timer"
+	case StopReasonStop:
+		return "stop"
+	case StopReasonProcessExit:
+		return "process-exit"
+	case StopReasonProcessError:
+		return "process-error"
+	case StopReasonProcessPanic:
+		return "process-panic"
+	case StopReasonHealthCheck:
+		return "health-check"
+	case StopReasonStartupTimeout:
+		return "startup-timeout"
+	case StopReasonHook:
+		return "hook"
+	case StopReasonReload:
+		return "reload"
+	default:
+		return "unknown"
+	}
+}
+
+// StopReason tells why the waitprocess stopped
+type StopReason struct {
+	Kind StopReasonKind
+	// Signal is the received signal for StopReasonSignal
+	Signal os.Signal
+	// Proc is the name of the process for the process, health check and reload kinds
+	Proc string
+	// Err is the error that caused the stop, if any
+	Err error
+}
+
+func (r StopReason) String() string {
+	switch r.Kind {
+	case StopReasonSignal:
+		return fmt.Sprintf("received signal %v", r.Signal)
+	case StopReasonContext:
+		return "context cancelled"
+	case StopReasonTimer:
+		return "timer expired"
+	case StopReasonStop:
+		return "stopped"
+	case StopReasonProcessExit:
+		return fmt.Sprintf("process %s exited", r.Proc)
+	case StopReasonProcessError:
+		return fmt.Sprintf("process %s failed: %v", r.Proc, r.Err)
+	case StopReasonProcessPanic:
+		return fmt.Sprintf("process %s panicked: %v", r.Proc, r.Err)
+	case StopReasonHealthCheck:
+		return fmt.Sprintf("process %s failed health check: %v", r.Proc, r.Err)
+	case StopReasonStartupTimeout:
+		return fmt.Sprintf("startup timeout: %v", r.Err)
+	case StopReasonHook:
+		return fmt.Sprintf("hook failed: %v", r.Err)
+	case StopReasonReload:
+		return fmt.Sprintf("process %s failed to reload: %v", r.Proc, r.Err)
+	default:
+		return "not stopped"
+	}
+}
+
+// StopReason returns why the waitprocess stopped, only the first reason is kept.
+// the kind is StopReasonNone while the waitprocess is running
+func (wp *WaitProcess) StopReason() StopReason {
+	if reason := atomic.LoadPointer(&wp.stopReason); reason != nil {
+		return *(*StopReason)(reason)
+	}
+	return StopReason{Kind: StopReasonNone}
+}
+
+// setStopReason records the reason if none is recorded yet, it must be called before
+// the waitprocess is cancelled
+func (wp *WaitProcess) setStopReason(reason StopReason) {
+	if atomic.CompareAndSwapPointer(&wp.stopReason, nil, unsafe.Pointer(&reason)) {
+		wp.log.WithField("reason", reason).Info("Stopping WaitProcess")
+		wp.events.emit(Event{Type: EventGroupStopping, Reason: reason})
+	}
+}
+
+// exitReason returns the stop reason for the exit of a process
+func exitReason(proc string, err error, panicked bool) StopReason {
+	switch {
+	case panicked:
+		return StopReason{Kind: StopReasonProcessPanic, Proc: proc, Err: err}
+	case err != nil:
+		return StopReason{Kind: StopReasonProcessError, Proc: proc, Err: err}
+	default:
+		return StopReason{Kind: StopReasonProcessExit, Proc: proc}
+	}
+}
@@ -0,0 +1,183 @@
+package waitprocess
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStopReason(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		defer wp.Shutdown()
+
+		assert.Equal(t, StopReasonNone, wp.StopReason().Kind)
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterSignal(syscall.SIGUSR2)
+		wp.Start()
+
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		err := wp.Wait()
+		assert.Nil(t, err)
+
+		reason := wp.StopReason()
+		assert.Equal(t, StopReasonSignal, reason.Kind)
+		assert.Equal(t, syscall.SIGUSR2, reason.Signal)
+		assert.Equal(t, "received signal user defined signal 2", reason.String())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		wp := NewWaitProcess(WithContext(ctx))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+
+		cancel()
+		wp.Wait()
+		assert.Equal(t, StopReasonContext, wp.StopReason().Kind)
+		assert.ErrorIs(t, wp.StopReason().Err, context.Canceled)
+	})
+
+	t.Run("timer", func(t *testing.T) {
+		wp := NewWaitProcess(WithTimer(10 * time.Millisecond))
+		wp.RegisterProcess("test", withTestprocess())
+
+		wp.Run()
+		assert.Equal(t, StopReasonTimer, wp.StopReason().Kind)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+
+		wp.Shutdown()
+		assert.Equal(t, StopReasonStop, wp.StopReason().Kind)
+	})
+
+	t.Run("process-exit", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("job", withSleepprocess(10*time.Millisecond))
+
+		wp.Run()
+		assert.Equal(t, StopReason{Kind: StopReasonProcessExit, Proc: "job"}, wp.StopReason())
+	})
+
+	t.Run("process-error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", withErrprocess(assert.AnError))
+
+		wp.Run()
+		reason := wp.StopReason()
+		assert.Equal(t, StopReasonProcessError, reason.Kind)
+		assert.Equal(t, "error", reason.Proc)
+		assert.ErrorIs(t, reason.Err, assert.AnError)
+	})
+
+	t.Run("process-panic", func(t *testing.T) {
+		wp := NewWaitProcess(WithPanicPolicy(PanicAsError))
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
+			panic("boom")
+		}))
+
+		wp.Run()
+		reason := wp.StopReason()
+		assert.Equal(t, StopReasonProcessPanic, reason.Kind)
+		assert.Equal(t, "panic", reason.Proc)
+	})
+
+	t.Run("health-check", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hp := withHealthprocess()
+		hp.setHealth(assert.AnError)
+		wp.RegisterProcess("health", hp, WithHealthCheck(HealthCheck{
+			Interval:         10 * time.Millisecond,
+			FailureThreshold: 1,
+			Action:           HealthStopGroup,
+		}))
+
+		wp.Run()
+		reason := wp.StopReason()
+		assert.Equal(t, StopReasonHealthCheck, reason.Kind)
+		assert.Equal(t, "health", reason.Proc)
+	})
+
+	t.Run("startup-timeout", func(t *testing.T) {
+		wp := NewWaitProcess(WithStartupTimeout(10 * time.Millisecond))
+		wp.RegisterProcess("never-ready", RunWithReady(func(ctx context.Context, ready func()) error {
+			<-ctx.Done()
+			return nil
+		}))
+
+		wp.Run()
+		assert.Equal(t, StopReasonStartupTimeout, wp.StopReason().Kind)
+	})
+}
+
+func TestRunWithReason(t *testing.T) {
+	t.Run("process-error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", withErrprocess(assert.AnError))
+
+		reason, err := wp.RunWithReason()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, StopReasonProcessError, reason.Kind)
+		assert.Equal(t, "error", reason.Proc)
+		assert.Equal(t, wp.StopReason(), reason)
+	})
+
+	t.Run("timer", func(t *testing.T) {
+		wp := NewWaitProcess(WithTimer(10 * time.Millisecond))
+		wp.RegisterProcess("test", withTestprocess())
+
+		reason, err := wp.RunWithReason()
+		assert.Nil(t, err)
+		assert.Equal(t, StopReasonTimer, reason.Kind)
+	})
+
+	t.Run("pre-start-hook", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHookWithCtx("hook", func(ctx context.Context) error {
+			return assert.AnError
+		})
+
+		reason, err := wp.RunWithReason()
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, StopReasonHook, reason.Kind)
+		assert.ErrorIs(t, reason.Err, assert.AnError)
+		assert.Equal(t, wp.StopReason(), reason)
+	})
+
+	t.Run("pre-start-hook-start", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.PreStartHookWithCtx("hook", func(ctx context.Context) error {
+			return assert.AnError
+		})
+
+		assert.ErrorIs(t, wp.Start(), assert.AnError)
+		assert.Equal(t, StopReasonHook, wp.StopReason().Kind)
+	})
+
+	t.Run("invalid-dependencies", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess(), DependsOn("unknown"))
+
+		reason, err := wp.RunWithReason()
+		assert.NotNil(t, err)
+		assert.Equal(t, StopReasonNone, reason.Kind)
+	})
+}
@@ -2,6 +2,7 @@ package waitprocess
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -91,3 +92,96 @@ func (tp *sleepprocess) Stop() {
 
 func (tp *sleepprocess) SetContext(_ context.Context) {
 }
+
+type healthprocess struct {
+	*testprocess
+	err atomic.Value
+}
+
+func withHealthprocess() *healthprocess {
+	return &healthprocess{testprocess: withTestprocess()}
+}
+
+func (hp *healthprocess) setHealth(err error) {
+	hp.err.Store(&err)
+}
+
+func (hp *healthprocess) Check(_ context.Context) error {
+	if err, ok := hp.err.Load().(*error); ok {
+		return *err
+	}
+	return nil
+}
+
+type checkprocess struct {
+	Process
+	check func(ctx context.Context) error
+}
+
+func (cp *checkprocess) Check(ctx context.Context) error {
+	return cp.check(ctx)
+}
+
+type reloadprocess struct {
+	*testprocess
+	reloads int32
+	err     error
+}
+
+func withReloadprocess(err error) *reloadprocess {
+	return &reloadprocess{testprocess: withTestprocess(), err: err}
+}
+
+func (rp *reloadprocess) getReloadCount() int {
+	return int(atomic.LoadInt32(&rp.reloads))
+}
+
+func (rp *reloadprocess) Reload(_ context.Context) error {
+	atomic.AddInt32(&rp.reloads, 1)
+	return rp.err
+}
+
+// slowstopprocess runs again after Stop, it returns when its context is done and Stop closes
+// the channel of the current run only after a while
+type slowstopprocess struct {
+	lock     sync.Mutex
+	ctx      context.Context
+	ch       chan struct{}
+	runCount int32
+}
+
+func (sp *slowstopprocess) getRunCount() int {
+	return int(atomic.LoadInt32(&sp.runCount))
+}
+
+func (sp *slowstopprocess) SetContext(ctx context.Context) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	sp.ctx = ctx
+	sp.ch = make(chan struct{})
+}
+
+func (sp *slowstopprocess) Run() error {
+	atomic.AddInt32(&sp.runCount, 1)
+	sp.lock.Lock()
+	ctx, ch := sp.ctx, sp.ch
+	sp.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-ch:
+	}
+	return nil
+}
+
+func (sp *slowstopprocess) Stop() {
+	time.Sleep(50 * time.Millisecond)
+
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	select {
+	case <-sp.ch:
+	default:
+		close(sp.ch)
+	}
+}
@@ -3,9 +3,10 @@ package waitprocess
 import (
 	"context"
 	"fmt"
-	"github.com/sirupsen/logrus"
+	"io"
 	"os"
-	"os/signal"
+	"runtime/pprof"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,27 +25,40 @@ func IsWaitTimeout(err error) bool {
 	return err == WaitTimeout
 }
 
-type hookFunc func()
-
-type hook struct {
-	name string
-	hook hookFunc
-}
-
 type WaitProcess struct {
-	ctx            context.Context
-	cancel         context.CancelFunc
-	state          int32
-	lock           sync.Mutex
-	log            *logrus.Entry
-	signalChan     chan os.Signal
-	procs          *orderMap[string, *procstat]
-	timer          *time.Timer
-	stopChan       chan struct{}
-	panicked       unsafe.Pointer
-	error          unsafe.Pointer
-	preStartHooks  *orderMap[string, hook]
-	afterStopHooks *orderMap[string, hook]
+	ctx             context.Context
+	cancel          context.CancelFunc
+	state           int32
+	lock            sync.Mutex
+	log             Logger
+	signalChan      chan os.Signal
+	signals         map[os.Signal]signalAction
+	procs           *orderMap[string, *procstat]
+	timer           *time.Timer
+	stopChan        chan struct{}
+	panicked        unsafe.Pointer
+	stopReason      unsafe.Pointer
+	errLock         sync.Mutex
+	errors          []error
+	preStartHooks   *orderMap[string, hook]
+	postStartHooks  *orderMap[string, hook]
+	preStopHooks    *orderMap[string, hook]
+	afterStopHooks  *orderMap[string, hook]
+	shutdownOrder   ShutdownOrder
+	shutdownTimeout time.Duration
+	abandoned       []string
+	shutdownErr     *ShutdownTimeoutError
+	readyChan       chan struct{}
+	order           []*procstat
+	closing         bool
+	startupTimeout  time.Duration
+	panicPolicy     PanicPolicy
+	onPanic         func(*ProcessError)
+	events          *eventBus
+	stopOnReload    bool
+	reloadLock      sync.Mutex
+	dumpWriter      io.Writer
+	escalation      *Escalation
 }
 
 // NewWaitProcess creates a new waitprocess
@@ -53,68 +67,220 @@ func NewWaitProcess(opts ...WaitProcessOption) *WaitProcess {
 
 	ctx, cancel := context.WithCancel(opt.ctx)
 	return &WaitProcess{
-		timer:          opt.timer,
-		ctx:            ctx,
-		cancel:         cancel,
-		log:            opt.log,
-		signalChan:     make(chan os.Signal, 1),
-		state:          stateReady,
-		stopChan:       make(chan struct{}),
-		procs:          newOrderMap[string, *procstat](),
-		preStartHooks:  newOrderMap[string, hook](),
-		afterStopHooks: newOrderMap[string, hook](),
+		timer:           opt.timer,
+		ctx:             ctx,
+		cancel:          cancel,
+		log:             opt.log,
+		signalChan:      make(chan os.Signal, 1),
+		signals:         make(map[os.Signal]signalAction),
+		state:           stateReady,
+		stopChan:        make(chan struct{}),
+		procs:           newOrderMap[string, *procstat](),
+		preStartHooks:   newOrderMap[string, hook](),
+		postStartHooks:  newOrderMap[string, hook](),
+		preStopHooks:    newOrderMap[string, hook](),
+		afterStopHooks:  newOrderMap[string, hook](),
+		shutdownOrder:   opt.order,
+		shutdownTimeout: opt.shutdownTimeout,
+		readyChan:       make(chan struct{}),
+		startupTimeout:  opt.startupTimeout,
+		panicPolicy:     opt.panicPolicy,
+		onPanic:         opt.onPanic,
+		events:          newEventBus(),
+		stopOnReload:    opt.stopOnReload,
+		dumpWriter:      opt.dumpWriter,
+		escalation:      opt.escalation,
 	}
 }
 
 func (wp *WaitProcess) ProcessCount() int {
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+
 	return wp.procs.size()
 }
 
-// RegisterProcess registers processes to be run by the waitprocess
-func (wp *WaitProcess) RegisterProcess(name string, procs Process) *WaitProcess {
+// RegisterProcess registers processes to be run by the waitprocess, if the waitprocess
+// is already running the process is started immediately
+func (wp *WaitProcess) RegisterProcess(name string, procs Process, opts ...ProcessOption) *WaitProcess {
 	wp.lock.Lock()
 	defer wp.lock.Unlock()
 
-	if wp.getState() != stateReady {
-		wp.log.Panic("Cannot call RegisterProcess() after WaitProcess has already started")
+	if wp.closing {
+		wp.panicf("Cannot call RegisterProcess() after WaitProcess has stopped")
 	}
 
 	if wp.procs.contains(name) {
-		wp.log.Panicf("Process %s already exists", name)
+		wp.panicf("Process %s already exists", name)
+	}
+
+	proc := newProcstat(name, procs, newProcessOption(opts...))
+	if wp.getState() != stateStarted {
+		wp.procs.set(name, proc)
+		return wp
+	}
+
+	if err := linkDependencies(wp.procs, proc); err != nil {
+		wp.panicf("Cannot register process: %v", err)
 	}
 
-	wp.procs.set(name, newProcstat(name, procs))
+	wp.procs.set(name, proc)
+	wp.order = append(wp.order, proc)
+	wp.launch(proc)
 	return wp
 }
 
-// RegisterSignal registers signals to be caught by the waitprocess
-func (wp *WaitProcess) RegisterSignal(sigs ...os.Signal) *WaitProcess {
+// RemoveProcess stops the named process and removes it from the waitprocess without stopping
+// the other processes. it waits for the process to exit, bounded by its stop timeout
+func (wp *WaitProcess) RemoveProcess(name string) error {
 	wp.lock.Lock()
-	defer wp.lock.Unlock()
 
-	if wp.state != stateReady {
-		wp.log.Panic("Cannot call RegisterSignal() after WaitProcess has already started")
+	ok, proc := wp.procs.load(name)
+	if !ok {
+		wp.lock.Unlock()
+		return fmt.Errorf("process %s doesn't exist", name)
 	}
 
-	signal.Notify(wp.signalChan, sigs...)
-	return wp
+	if dependents := dependentsOf(wp.procs, name); len(dependents) > 0 {
+		wp.lock.Unlock()
+		return fmt.Errorf("process %s is depended on by %s", name, strings.Join(dependents, ", "))
+	}
+
+	if wp.closing {
+		wp.lock.Unlock()
+		return fmt.Errorf("cannot remove process %s, WaitProcess is stopping", name)
+	}
+
+	wp.procs.delete(name)
+	if wp.getState() != stateStarted {
+		wp.lock.Unlock()
+		return nil
+	}
+
+	unlinkDependencies(proc)
+	order := make([]*procstat, 0, len(wp.order))
+	for _, p := range wp.order {
+		if p != proc {
+			order = append(order, p)
+		}
+	}
+	wp.order = order
+	proc.detach()
+	wp.lock.Unlock()
+
+	wp.log.WithField("proc", name).Debug("Removing process")
+	if !proc.stopAndWait() {
+		return &StopTimeoutError{Procs: []string{name}}
+	}
+
+	return nil
 }
 
-// Start starts the waitprocess
-func (wp *WaitProcess) Start() {
+// StopProcess stops the named process without stopping the other processes, it stays
+// registered as stopped. it waits for the process to exit, bounded by its stop timeout
+func (wp *WaitProcess) StopProcess(name string) error {
+	wp.lock.Lock()
+
+	ok, proc := wp.procs.load(name)
+	if !ok {
+		wp.lock.Unlock()
+		return fmt.Errorf("process %s doesn't exist", name)
+	}
+
+	if wp.getState() != stateStarted || wp.closing {
+		wp.lock.Unlock()
+		return fmt.Errorf("cannot stop process %s, WaitProcess is not running", name)
+	}
+
+	running := make([]string, 0)
+	for _, dependent := range proc.dependents {
+		if !dependent.exited() {
+			running = append(running, dependent.name)
+		}
+	}
+
+	if len(running) > 0 {
+		wp.lock.Unlock()
+		return fmt.Errorf("process %s is depended on by %s", name, strings.Join(running, ", "))
+	}
+
+	if proc.isStopping() || proc.exited() {
+		wp.lock.Unlock()
+		return fmt.Errorf("process %s is already stopped", name)
+	}
+
+	proc.detach()
+	wp.lock.Unlock()
+
+	wp.log.WithField("proc", name).Debug("Stopping process on request")
+	if !proc.stopAndWait() {
+		return &StopTimeoutError{Procs: []string{name}}
+	}
+
+	return nil
+}
+
+// RestartProcess stops the current run of the named process and runs it again immediately,
+// the process must stay runnable after Stop, see Process
+func (wp *WaitProcess) RestartProcess(name string) error {
+	wp.lock.Lock()
+	ok, proc := wp.procs.load(name)
+	wp.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("process %s doesn't exist", name)
+	}
+
+	wp.log.WithField("proc", name).Debug("Restarting process on request")
+	if !proc.restart() {
+		return fmt.Errorf("process %s is not running", name)
+	}
+
+	return nil
+}
+
+// RegisterSignal registers signals that stop the waitprocess
+func (wp *WaitProcess) RegisterSignal(sigs ...os.Signal) *WaitProcess {
+	return wp.RegisterSignalAction(SignalStop, sigs...)
+}
+
+// Start starts the waitprocess, it returns the error of a failing pre-start hook or of
+// invalid dependencies, no process is launched then. a failing pre-start hook is recorded
+// as StopReasonHook
+func (wp *WaitProcess) Start() error {
 	wp.lock.Lock()
 	defer wp.lock.Unlock()
-	wp.start()
+	return wp.start()
 }
 
 // Run starts the waitprocess and waits for it to stop
 func (wp *WaitProcess) Run() error {
 	wp.lock.Lock()
+	err := wp.start()
 	wp.lock.Unlock()
-	wp.start()
+
+	if err != nil {
+		return err
+	}
+
 	return wp.wait()
 }
 
+// RunWithReason starts the waitprocess, waits for it to stop and returns why it stopped with
+// the error of Run. a failing pre-start hook is reported as StopReasonHook
+func (wp *WaitProcess) RunWithReason() (StopReason, error) {
+	wp.lock.Lock()
+	err := wp.start()
+	wp.lock.Unlock()
+
+	if err != nil {
+		return wp.StopReason(), err
+	}
+
+	err = wp.wait()
+	return wp.StopReason(), err
+}
+
 // Stop stops the waitprocess
 func (wp *WaitProcess) Stop() {
 	wp.lock.Lock()
@@ -133,22 +299,19 @@ func (wp *WaitProcess) Stopped() bool {
 
 // Wait waits for the waitprocess to stop
 func (wp *WaitProcess) Wait(timeout ...time.Duration) error {
-	wp.lock.Lock()
-	defer wp.lock.Unlock()
-
 	return wp.wait(timeout...)
 }
 
 // Shutdown stops the waitprocess and waits for it to stop
 func (wp *WaitProcess) Shutdown(timeout ...time.Duration) error {
 	wp.lock.Lock()
-	defer wp.lock.Unlock()
-
 	wp.stop()
+	wp.lock.Unlock()
+
 	return wp.wait(timeout...)
 }
 
-// Error returns the error of the waitprocess, only return the first error
+// Error returns the errors of the waitprocess as a MultiError, nil if there are none
 func (wp *WaitProcess) Error() error {
 	wp.lock.Lock()
 	defer wp.lock.Unlock()
@@ -158,36 +321,22 @@ func (wp *WaitProcess) Error() error {
 
 // PreStartHook adds a hook to be run before the waitprocess starts
 func (wp *WaitProcess) PreStartHook(name string, f hookFunc) *WaitProcess {
-	wp.lock.Lock()
-	defer wp.lock.Unlock()
-
-	if wp.getState() != stateReady {
-		wp.log.Panic("Cannot call PreStartHook() after WaitProcess has already started")
-	}
+	return wp.addHook("PreStartHook", wp.preStartHooks, newHook(name, f.withCtx()))
+}
 
-	if wp.preStartHooks.contains(name) {
-		wp.log.Panicf("PreStartHook %s already exists", name)
-	}
+// PostStartHook adds a hook to be run once all processes are ready
+func (wp *WaitProcess) PostStartHook(name string, f hookFunc) *WaitProcess {
+	return wp.addHook("PostStartHook", wp.postStartHooks, newHook(name, f.withCtx()))
+}
 
-	wp.preStartHooks.set(name, hook{name: name, hook: f})
-	return wp
+// PreStopHook adds a hook to be run when the waitprocess stops, before any process is stopped
+func (wp *WaitProcess) PreStopHook(name string, f hookFunc) *WaitProcess {
+	return wp.addHook("PreStopHook", wp.preStopHooks, newHook(name, f.withCtx()))
 }
 
 // AfterStopHook adds a hook to be run after the waitprocess stops
 func (wp *WaitProcess) AfterStopHook(name string, f hookFunc) *WaitProcess {
-	wp.lock.Lock()
-	defer wp.lock.Unlock()
-
-	if wp.getState() != stateReady {
-		wp.log.Panic("Cannot call AfterStopHook() after WaitProcess has already started")
-	}
-
-	if wp.afterStopHooks.contains(name) {
-		wp.log.Panicf("AfterStopHook %s already exists", name)
-	}
-
-	wp.afterStopHooks.set(name, hook{name: name, hook: f})
-	return wp
+	return wp.addHook("AfterStopHook", wp.afterStopHooks, newHook(name, f.withCtx()))
 }
 
 func (wp *WaitProcess) getState() int32 {
@@ -198,98 +347,134 @@ func (wp *WaitProcess) setState(state int32) {
 	atomic.CompareAndSwapInt32(&wp.state, stateReady, state)
 }
 
-func (wp *WaitProcess) start() {
+func (wp *WaitProcess) start() error {
 	if wp.getState() != stateReady {
-		wp.log.Panic("Cannot call Start() after WaitProcess has already started")
+		wp.panicf("Cannot call Start() after WaitProcess has already started")
 	}
 
 	if wp.procs.size() == 0 {
-		wp.log.Panic("Cannot start WaitProcess without any processes")
+		wp.panicf("Cannot start WaitProcess without any processes")
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(wp.procs.size())
+	order, err := resolveDependencies(wp.procs)
+	if err != nil {
+		wp.log.WithError(err).Error("Invalid process dependencies, WaitProcess not started")
+		return err
+	}
 
-	wp.preStartHooks.rangeFunc(func(index int, key string, value hook) bool {
-		value.hook()
-		return true
-	})
-
-	wp.procs.rangeFunc(func(_ int, key string, proc *procstat) bool {
-		log := wp.log.WithField("proc", proc)
-		log.Debug("Starting process")
-		proc.setContext(wp.ctx)
-
-		go func() {
-			defer func() {
-				if panicked := proc.getPanicked(); panicked != nil {
-					atomic.CompareAndSwapPointer(&wp.panicked, nil, panicked)
-					log.WithField("panic", panicked).Error("Process panicked")
+	wp.events.emit(Event{Type: EventGroupStarting})
+	if err := wp.runPreStartHooks(); err != nil {
+		wp.log.WithError(err).Error("Pre-start hook failed, WaitProcess not started")
+		wp.setStopReason(StopReason{Kind: StopReasonHook, Err: err})
+		return err
+	}
+
+	wp.order = order
+	for _, proc := range order {
+		wp.launch(proc)
+	}
+
+	go wp.watchReady(order)
+
+	go wp.watchStop()
+
+	wp.setState(stateStarted)
+	wp.events.emit(Event{Type: EventGroupStarted})
+	wp.log.Info("WaitProcess started")
+	return nil
+}
+
+// launch runs the process in its own goroutine, its exit stops the waitprocess according to
+// the exit policy, unless it was removed or stopped on its own
+func (wp *WaitProcess) launch(proc *procstat) {
+	log := wp.log.WithField("proc", proc.name)
+	log.Debug("Starting process")
+	proc.setContext(wp.ctx)
+	proc.panicPolicy = wp.panicPolicy
+	proc.onPanic = wp.onPanic
+	proc.events = wp.events
+
+	go func() {
+		// the goroutines of the process are labelled with its name in goroutine dumps
+		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("process", proc.name)))
+
+		stopGroup := true
+		var reason *StopReason
+		defer func() {
+			if stopGroup && !proc.isDetached() {
+				if reason != nil {
+					wp.setStopReason(*reason)
 				}
-				wg.Done()
 				wp.cancel()
-			}()
-
-			if err := proc.run(); err != nil {
-				atomic.CompareAndSwapPointer(&wp.error, nil, unsafe.Pointer(&err))
-				log.WithField("error", err).Error("Process error")
 			}
-
-			log.Debug("Process stopped")
+			close(proc.done)
 		}()
-		return true
-	})
 
-	go func() {
-		var timer <-chan time.Time
-
-		if wp.timer != nil {
-			timer = wp.timer.C
-		} else {
-			tch := make(chan time.Time)
-			defer close(tch)
-			timer = tch
+		if !proc.waitDependencies() {
+			log.Debug("Process stopped before its dependencies were ready")
+			return
 		}
 
-		select {
-		case <-wp.signalChan:
-			wp.log.Debug("Received signal, stopping WaitProcess")
-		case <-wp.ctx.Done():
-			wp.log.Debug("Context done, stopping WaitProcess")
-		case <-timer:
-			wp.log.Debug("Timer done, stopping WaitProcess")
+		err := proc.run(log)
+		panicked := proc.getPanicked()
+		exit := exitReason(proc.name, err, panicked != nil)
+		reason = &exit
+		if panicked != nil && wp.panicPolicy == PanicRePanic {
+			if !proc.isDetached() {
+				atomic.CompareAndSwapPointer(&wp.panicked, nil, panicked)
+				wp.addError(err)
+			}
+			return
 		}
 
-		wp.procs.rangeFunc(func(_ int, name string, proc *procstat) bool {
-			wp.log.WithField("proc", name).Debug("Stopping process")
-			proc.stop()
-			return true
-		})
+		stopGroup = proc.opt.exitPolicy.stopsGroup(err)
+		if err != nil {
+			if stopGroup && !proc.isDetached() {
+				if panicked != nil {
+					// err is already the ProcessError of the panic
+					wp.addError(err)
+				} else {
+					phase := PhaseRun
+					if proc.isStopping() {
+						phase = PhaseStop
+					}
+					wp.addError(proc.newError(phase, err))
+				}
+			}
+
+			if panicked == nil {
+				log.WithField("error", err).Error("Process error")
+			}
+		} else if !stopGroup {
+			// a process that finished its work doesn't hold back the readiness of the others
+			proc.markReady()
+		}
 
-		wg.Wait()
-		close(wp.stopChan)
+		if !stopGroup {
+			log.WithField("policy", proc.opt.exitPolicy).Debug("Process exited, WaitProcess keeps running")
+			return
+		}
 
-		wp.afterStopHooks.rangeFunc(func(index int, key string, value hook) bool {
-			value.hook()
-			return true
-		})
+		log.Debug("Process stopped")
 	}()
 
-	wp.setState(stateStarted)
-	wp.log.Info("WaitProcess started")
+	if checker, ok := proc.proc.(HealthChecker); ok {
+		go wp.watchHealth(proc, checker)
+	}
 }
 
 func (wp *WaitProcess) stop() {
 	if wp.getState() != stateStarted {
-		wp.log.Panic("Cannot call Stop() before WaitProcess has started")
+		wp.panicf("Cannot call Stop() before WaitProcess has started")
 	}
 
+	wp.setStopReason(StopReason{Kind: StopReasonStop})
 	wp.cancel()
 }
 
 func (wp *WaitProcess) wait(timeout ...time.Duration) error {
 	if wp.getState() != stateStarted {
-		wp.log.Panic("Cannot call Wait() before WaitProcess has started")
+		wp.panicf("Cannot call Wait() before WaitProcess has started")
 	}
 
 	if len(timeout) > 0 {
@@ -309,13 +494,34 @@ func (wp *WaitProcess) wait(timeout ...time.Duration) error {
 	return wp.getError()
 }
 
+func (wp *WaitProcess) addError(err error) {
+	wp.errLock.Lock()
+	defer wp.errLock.Unlock()
+	wp.errors = append(wp.errors, err)
+}
+
 func (wp *WaitProcess) getError() error {
 	if wp.getState() != stateStarted {
-		wp.log.Panic("Cannot call Error() before WaitProcess has started")
+		wp.panicf("Cannot call Error() before WaitProcess has started")
+	}
+
+	wp.errLock.Lock()
+	errs := append([]error{}, wp.errors...)
+	wp.errLock.Unlock()
+
+	// abandoned and shutdownErr are only written before stopChan is closed
+	if wp.Stopped() {
+		if len(wp.abandoned) > 0 {
+			errs = append(errs, &StopTimeoutError{Procs: wp.abandoned})
+		}
+
+		if wp.shutdownErr != nil {
+			errs = append(errs, wp.shutdownErr)
+		}
 	}
 
-	if err := atomic.LoadPointer(&wp.error); err != nil {
-		return *(*error)(err)
+	if len(errs) == 0 {
+		return nil
 	}
-	return nil
+	return &MultiError{Errors: errs}
 }
@@ -26,8 +26,27 @@ func TestRegisterProcess(t *testing.T) {
 
 		wp.Start()
 
+		tp := withTestprocess()
+		wp.RegisterProcess("test2", tp)
+		assert.Equal(t, 2, wp.ProcessCount(), "process count should be 2")
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 1, tp.getRunCount(), "run count should be 1")
+
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, tp.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("wp-started-and-register-with-dependency", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test1", withTestprocess())
+		wp.Start()
+		defer wp.Shutdown()
+
+		wp.RegisterProcess("test2", withTestprocess(), DependsOn("test1"))
 		assert.Panics(t, func() {
-			wp.RegisterProcess("test2", withTestprocess())
+			wp.RegisterProcess("test3", withTestprocess(), DependsOn("unknown"))
 		})
 	})
 
@@ -55,6 +74,213 @@ func TestRegisterProcess(t *testing.T) {
 	})
 }
 
+func TestStopProcess(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp1 := withTestprocess()
+		tp2 := withTestprocess()
+		wp.RegisterProcess("test1", tp1).RegisterProcess("test2", tp2)
+		wp.Start()
+
+		err := wp.StopProcess("test2")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, wp.ProcessCount(), "process count should be 2")
+		assert.Equal(t, 1, tp2.getStopCount(), "stop count should be 1")
+
+		time.Sleep(50 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+		assert.Equal(t, ProcessStopped, wp.Snapshot()[1].State)
+
+		err = wp.StopProcess("test2")
+		assert.EqualError(t, err, "process test2 is already stopped")
+
+		err = wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, tp1.getStopCount(), "stop count should be 1")
+		assert.Equal(t, 1, tp2.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("depended-on", func(t *testing.T) {
+		wp := NewWaitProcess(WithShutdownOrder(ShutdownReverse))
+		wp.RegisterProcess("db", withTestprocess())
+		wp.RegisterProcess("http", withTestprocess(), DependsOn("db"))
+		wp.Start()
+
+		err := wp.StopProcess("db")
+		assert.EqualError(t, err, "process db is depended on by http")
+
+		assert.Nil(t, wp.StopProcess("http"))
+		assert.Nil(t, wp.StopProcess("db"))
+		assert.Nil(t, wp.Shutdown())
+	})
+
+	t.Run("not-running", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+
+		err := wp.StopProcess("test")
+		assert.EqualError(t, err, "cannot stop process test, WaitProcess is not running")
+
+		err = wp.StopProcess("unknown")
+		assert.EqualError(t, err, "process unknown doesn't exist")
+	})
+}
+
+func TestRestartProcess(t *testing.T) {
+	wp := NewWaitProcess()
+	stat := &teststate{}
+	wp.RegisterProcess("test", RunWithCtx(func(ctx context.Context) error {
+		stat.add()
+		<-ctx.Done()
+		return nil
+	}))
+	wp.Start()
+
+	assert.Eventually(t, func() bool {
+		return stat.getstate() == 1
+	}, time.Second, time.Millisecond)
+
+	assert.Nil(t, wp.RestartProcess("test"))
+	assert.Eventually(t, func() bool {
+		return stat.getstate() == 2
+	}, time.Second, time.Millisecond)
+	assert.False(t, wp.Stopped(), "wp should not be stopped")
+	assert.Equal(t, 1, wp.Snapshot()[0].Restarts)
+
+	assert.EqualError(t, wp.RestartProcess("unknown"), "process unknown doesn't exist")
+	assert.Nil(t, wp.Shutdown())
+	assert.EqualError(t, wp.RestartProcess("test"), "process test is not running")
+}
+
+func TestRestartProcessSlowStop(t *testing.T) {
+	wp := NewWaitProcess()
+	sp := &slowstopprocess{}
+	wp.RegisterProcess("test", sp)
+	wp.Start()
+
+	assert.Eventually(t, func() bool {
+		return sp.getRunCount() == 1
+	}, time.Second, time.Millisecond)
+
+	// the next run starts once Stop returned, the Stop of the previous run doesn't stop it
+	assert.Nil(t, wp.RestartProcess("test"))
+	assert.Eventually(t, func() bool {
+		return sp.getRunCount() == 2
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, wp.Stopped(), "wp should not be stopped")
+	assert.Equal(t, 2, sp.getRunCount())
+	assert.Nil(t, wp.Shutdown())
+}
+
+func TestRemoveProcess(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp1 := withTestprocess()
+		tp2 := withTestprocess()
+		wp.RegisterProcess("test1", tp1).RegisterProcess("test2", tp2)
+		wp.Start()
+
+		err := wp.RemoveProcess("test2")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, wp.ProcessCount(), "process count should be 1")
+		assert.Equal(t, 1, tp2.getStopCount(), "stop count should be 1")
+
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, wp.Stopped(), "wp should not be stopped")
+
+		err = wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, tp1.getStopCount(), "stop count should be 1")
+		assert.Equal(t, 1, tp2.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("error-ignored", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("error", RunWithCtx(func(ctx context.Context) error {
+			<-ctx.Done()
+			return assert.AnError
+		}))
+		wp.Start()
+
+		err := wp.RemoveProcess("error")
+		assert.Nil(t, err)
+
+		err = wp.Shutdown()
+		assert.Nil(t, err)
+	})
+
+	t.Run("before-start", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp := withTestprocess()
+		wp.RegisterProcess("test1", withTestprocess()).RegisterProcess("test2", tp)
+
+		err := wp.RemoveProcess("test2")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, wp.ProcessCount(), "process count should be 1")
+
+		wp.Start()
+		err = wp.Shutdown()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, tp.getRunCount(), "run count should be 0")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		wp := NewWaitProcess()
+		err := wp.RemoveProcess("test")
+		assert.EqualError(t, err, "process test doesn't exist")
+	})
+
+	t.Run("depended-on", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("db", withTestprocess())
+		wp.RegisterProcess("http", withTestprocess(), DependsOn("db"))
+		wp.Start()
+		defer wp.Shutdown()
+
+		err := wp.RemoveProcess("db")
+		assert.EqualError(t, err, "process db is depended on by http")
+
+		err = wp.RemoveProcess("http")
+		assert.Nil(t, err)
+
+		err = wp.RemoveProcess("db")
+		assert.Nil(t, err)
+	})
+
+	t.Run("stop-timeout", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hang := make(chan struct{})
+		defer close(hang)
+
+		wp.RegisterProcess("test", withTestprocess())
+		wp.RegisterProcess("hang", RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {}), WithStopTimeout(100*time.Millisecond))
+		wp.Start()
+		defer wp.Shutdown()
+
+		err := wp.RemoveProcess("hang")
+		var stopErr *StopTimeoutError
+		assert.ErrorAs(t, err, &stopErr)
+		assert.Equal(t, []string{"hang"}, stopErr.Procs)
+	})
+
+	t.Run("after-stopped", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess())
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+
+		err = wp.RemoveProcess("test")
+		assert.NotNil(t, err)
+	})
+}
+
 func TestRegisterSignal(t *testing.T) {
 	t.Run("signal", func(t *testing.T) {
 		wp := NewWaitProcess()
@@ -106,7 +332,6 @@ func TestPanic(t *testing.T) {
 		wp.RegisterProcess("test", tp)
 		wp.RegisterProcess("panic", RunWithCtx(func(ctx context.Context) error {
 			panic("panic")
-			return nil
 		}))
 
 		assert.Panics(t, func() {
@@ -417,6 +642,66 @@ func TestShutdown(t *testing.T) {
 	})
 }
 
+func TestWithStopTimeout(t *testing.T) {
+	t.Run("abandoned", func(t *testing.T) {
+		wp := NewWaitProcess()
+		tp := withTestprocess()
+		hang := make(chan struct{})
+		defer close(hang)
+
+		wp.RegisterProcess("test", tp)
+		wp.RegisterProcess("hang1", RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {}), WithStopTimeout(100*time.Millisecond))
+		wp.RegisterProcess("hang2", RunWithStopFunc(func() error {
+			return nil
+		}, func() {
+			<-hang
+		}), WithStopTimeout(100*time.Millisecond))
+
+		wp.Start()
+		err := wp.Shutdown(time.Second)
+
+		var stopErr *StopTimeoutError
+		assert.ErrorAs(t, err, &stopErr)
+		assert.Equal(t, []string{"hang1", "hang2"}, stopErr.Procs)
+		assert.True(t, wp.procs.get("hang1").isAbandoned())
+		assert.True(t, wp.procs.get("hang2").isAbandoned())
+		assert.False(t, wp.procs.get("test").isAbandoned())
+		assert.Equal(t, 1, tp.getStopCount(), "stop count should be 1")
+	})
+
+	t.Run("with-process-error", func(t *testing.T) {
+		wp := NewWaitProcess()
+		hang := make(chan struct{})
+		defer close(hang)
+
+		wp.RegisterProcess("error", withErrprocess(assert.AnError))
+		wp.RegisterProcess("hang", RunWithStopFunc(func() error {
+			<-hang
+			return nil
+		}, func() {}), WithStopTimeout(100*time.Millisecond))
+
+		err := wp.Run()
+		assert.ErrorIs(t, err, assert.AnError)
+
+		var stopErr *StopTimeoutError
+		assert.ErrorAs(t, err, &stopErr)
+		assert.Equal(t, []string{"hang"}, stopErr.Procs)
+	})
+
+	t.Run("stopped-in-time", func(t *testing.T) {
+		wp := NewWaitProcess()
+		wp.RegisterProcess("test", withTestprocess(), WithStopTimeout(time.Second))
+
+		wp.Start()
+		err := wp.Shutdown()
+		assert.Nil(t, err)
+		assert.False(t, wp.procs.get("test").isAbandoned())
+	})
+}
+
 func TestStopped(t *testing.T) {
 	t.Run("normal", func(t *testing.T) {
 		wp := NewWaitProcess()
@@ -462,6 +747,12 @@ func TestNewProcess(t *testing.T) {
 	t.Run("log", func(t *testing.T) {
 		log := logrus.WithField("pkg", "waitprocess")
 		wp := NewWaitProcess(WithLog(log))
+		assert.Equal(t, NewLogrusLogger(log), wp.log)
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		log := NewNopLogger()
+		wp := NewWaitProcess(WithLogger(log))
 		assert.Equal(t, log, wp.log)
 	})
 }